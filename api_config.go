@@ -1,9 +1,13 @@
 package main
 
 import (
+	"database/sql"
+
 	"github.com/timokae/boot.dev-aggregator/internal/database"
 )
 
 type apiConfig struct {
-	DB *database.Queries
+	Conn    *sql.DB
+	DB      *database.Queries
+	Fetcher *feedFetcher
 }
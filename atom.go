@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/xml"
+	"html"
+	"strings"
+)
+
+type Atom struct {
+	XMLName  xml.Name     `xml:"feed"`
+	Text     string       `xml:",chardata"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Lang     string       `xml:"lang,attr"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle"`
+	Updated  string       `xml:"updated"`
+	Icon     string       `xml:"icon"`
+	Logo     string       `xml:"logo"`
+	Link     []AtomLink   `xml:"link"`
+	Author   []AtomPerson `xml:"author"`
+	Entry    []struct {
+		Text      string       `xml:",chardata"`
+		ID        string       `xml:"id"`
+		Title     string       `xml:"title"`
+		Link      []AtomLink   `xml:"link"`
+		Updated   string       `xml:"updated"`
+		Published string       `xml:"published"`
+		Summary   AtomText     `xml:"summary"`
+		Content   AtomText     `xml:"content"`
+		Author    []AtomPerson `xml:"author"`
+		Category  []struct {
+			Term  string `xml:"term,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+// AtomText is an Atom text construct. Text content is plain text, which may
+// contain escaped markup, and XHTML content is markup inside of a wrapping
+// div, which is lost when only the character data is read.
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// HTML returns the content of the text construct as it is passed on to the
+// sanitizer.
+func (text AtomText) HTML() string {
+	if text.Type == "" || text.Type == "text" {
+		return html.EscapeString(text.Text)
+	}
+
+	if text.Type != "xhtml" {
+		return text.Text
+	}
+
+	inner := strings.TrimSpace(text.Inner)
+	start := strings.Index(inner, ">")
+	end := strings.LastIndex(inner, "</")
+	if !strings.HasPrefix(inner, "<div") || start < 0 || end < start {
+		return inner
+	}
+
+	return strings.TrimSpace(inner[start+1 : end])
+}
+
+type AtomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// alternateLink returns the href of the rel="alternate" link. A link without
+// a rel attribute is an alternate link by definition of the Atom spec.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+
+	return ""
+}
+
+func atomEnclosures(links []AtomLink) []feedEnclosure {
+	enclosures := make([]feedEnclosure, 0)
+	for _, link := range links {
+		if link.Rel != "enclosure" || strings.TrimSpace(link.Href) == "" {
+			continue
+		}
+
+		enclosures = append(enclosures, feedEnclosure{
+			URL:    strings.TrimSpace(link.Href),
+			Type:   link.Type,
+			Length: int64(atoiOrZero(link.Length)),
+		})
+	}
+
+	return enclosures
+}
+
+func (atom Atom) toFeedDocument() feedDocument {
+	icon := atom.Icon
+	if icon == "" {
+		icon = atom.Logo
+	}
+
+	doc := feedDocument{
+		Format:      "atom",
+		Title:       atom.Title,
+		Description: atom.Subtitle,
+		Link:        alternateLink(atom.Link),
+		Language:    atom.Lang,
+		Icon:        strings.TrimSpace(icon),
+		Updated:     atom.Updated,
+		Items:       make([]feedItem, 0, len(atom.Entry)),
+	}
+
+	for _, entry := range atom.Entry {
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		description := entry.Summary.HTML()
+		if description == "" {
+			description = entry.Content.HTML()
+		}
+
+		// Entries without an author inherit the authors of the feed.
+		people := entry.Author
+		if len(people) == 0 {
+			people = atom.Author
+		}
+		authors := make([]string, 0, len(people))
+		for _, person := range people {
+			authors = append(authors, person.Name)
+		}
+
+		// The term is what feeds link to and filter by, the label only a
+		// human readable name for it, so both are kept.
+		categories := make([]string, 0, len(entry.Category))
+		for _, category := range entry.Category {
+			categories = append(categories, category.Term, category.Label)
+		}
+
+		doc.Items = append(doc.Items, feedItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Link),
+			PubDate:     pubDate,
+			Guid:        entry.ID,
+			Description: description,
+			Content:     entry.Content.HTML(),
+			Enclosures:  atomEnclosures(entry.Link),
+			Authors:     uniqueStrings(authors),
+			Categories:  uniqueStrings(categories),
+		})
+	}
+
+	return doc
+}
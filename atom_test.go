@@ -0,0 +1,170 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+  <id>urn:uuid:60a76c80-d399-11d9-b93c-0003939e0af6</id>
+  <title>Example Blog</title>
+  <subtitle>Notes on Go</subtitle>
+  <updated>2024-05-02T10:00:00Z</updated>
+  <logo>https://example.com/logo.png</logo>
+  <link rel="self" href="https://example.com/atom.xml"/>
+  <link href="https://example.com/"/>
+  <author><name>Jane Doe</name></author>
+  <entry>
+    <id>tag:example.com,2024:2</id>
+    <title>Second post</title>
+    <link rel="enclosure" href="https://example.com/talk.mp3" type="audio/mpeg" length="1234"/>
+    <link rel="alternate" href="https://example.com/posts/2"/>
+    <updated>2024-05-02T10:00:00Z</updated>
+    <published>2024-05-02T09:00:00Z</published>
+    <summary>Use &lt;script&gt; tags &amp; &lt;b&gt;bold&lt;/b&gt;</summary>
+    <author><name>John Roe</name></author>
+    <category term="golang" label="Go"/>
+    <category term="releases"/>
+    <category term="go" label="Go"/>
+  </entry>
+  <entry>
+    <id>tag:example.com,2024:1</id>
+    <title>First post</title>
+    <link href="https://example.com/posts/1"/>
+    <updated>2024-05-01T10:00:00Z</updated>
+    <content type="html">&lt;p&gt;Hello &lt;em&gt;world&lt;/em&gt;&lt;/p&gt;</content>
+  </entry>
+</feed>`
+
+func TestParseFeedAtom(t *testing.T) {
+	doc, err := parseFeed([]byte(atomFixture), "application/atom+xml")
+	if err != nil {
+		t.Fatalf("parseFeed returned error: %v", err)
+	}
+
+	if doc.Format != "atom" {
+		t.Errorf("Format = %q, want %q", doc.Format, "atom")
+	}
+	if doc.Title != "Example Blog" || doc.Description != "Notes on Go" || doc.Language != "en" {
+		t.Errorf("metadata = %q, %q, %q", doc.Title, doc.Description, doc.Language)
+	}
+	if doc.Link != "https://example.com/" {
+		t.Errorf("Link = %q, want the alternate link", doc.Link)
+	}
+	if doc.Icon != "https://example.com/logo.png" {
+		t.Errorf("Icon = %q, want the logo", doc.Icon)
+	}
+	if len(doc.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(doc.Items))
+	}
+
+	tests := []struct {
+		name string
+		got  feedItem
+		want feedItem
+	}{
+		{
+			name: "entry with enclosure and own author",
+			got:  doc.Items[0],
+			want: feedItem{
+				Title:       "Second post",
+				Link:        "https://example.com/posts/2",
+				PubDate:     "2024-05-02T09:00:00Z",
+				Guid:        "tag:example.com,2024:2",
+				Description: "Use &lt;script&gt; tags &amp; &lt;b&gt;bold&lt;/b&gt;",
+				Content:     "",
+				Enclosures: []feedEnclosure{
+					{URL: "https://example.com/talk.mp3", Type: "audio/mpeg", Length: 1234},
+				},
+				Authors:    []string{"John Roe"},
+				Categories: []string{"golang", "Go", "releases"},
+			},
+		},
+		{
+			name: "entry inheriting the feed author",
+			got:  doc.Items[1],
+			want: feedItem{
+				Title:       "First post",
+				Link:        "https://example.com/posts/1",
+				PubDate:     "2024-05-01T10:00:00Z",
+				Guid:        "tag:example.com,2024:1",
+				Description: "<p>Hello <em>world</em></p>",
+				Content:     "<p>Hello <em>world</em></p>",
+				Enclosures:  []feedEnclosure{},
+				Authors:     []string{"Jane Doe"},
+				Categories:  []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("item = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtomTextHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		element   string
+		want      string
+		sanitized string
+	}{
+		{
+			name:      "omitted type is plain text",
+			element:   `<summary>Use &lt;script&gt; tags &amp; &lt;b&gt;bold&lt;/b&gt;</summary>`,
+			want:      "Use &lt;script&gt; tags &amp; &lt;b&gt;bold&lt;/b&gt;",
+			sanitized: "Use &lt;script&gt; tags &amp; &lt;b&gt;bold&lt;/b&gt;",
+		},
+		{
+			name:      "text type is plain text",
+			element:   `<summary type="text">Wrap it in a &lt;template&gt; element</summary>`,
+			want:      "Wrap it in a &lt;template&gt; element",
+			sanitized: "Wrap it in a &lt;template&gt; element",
+		},
+		{
+			name:      "html type is escaped markup",
+			element:   `<summary type="html">&lt;p&gt;Hello&lt;/p&gt;&lt;script&gt;alert(1)&lt;/script&gt;</summary>`,
+			want:      "<p>Hello</p><script>alert(1)</script>",
+			sanitized: "<p>Hello</p>",
+		},
+		{
+			name:      "html type in CDATA",
+			element:   `<summary type="html"><![CDATA[<p>Hello <b>world</b></p>]]></summary>`,
+			want:      "<p>Hello <b>world</b></p>",
+			sanitized: "<p>Hello <b>world</b></p>",
+		},
+		{
+			name:      "xhtml type keeps the markup inside the div",
+			element:   `<summary type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div></summary>`,
+			want:      "<p>Hello <b>world</b></p>",
+			sanitized: "<p>Hello <b>world</b></p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` + tt.element + `</entry></feed>`
+
+			doc, err := parseFeed([]byte(data), "")
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+			if len(doc.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(doc.Items))
+			}
+
+			got := doc.Items[0].Description
+			if got != tt.want {
+				t.Errorf("Description = %q, want %q", got, tt.want)
+			}
+			if sanitized := sanitizeHTML(got, nil); sanitized != tt.sanitized {
+				t.Errorf("sanitizeHTML(Description) = %q, want %q", sanitized, tt.sanitized)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// pubDateLayouts are the publication date layouts seen in real world feeds,
+// ordered roughly by how common they are.
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 02 Jan 06 15:04:05 -0700",
+	"Mon, 02 Jan 06 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"02 Jan 06 15:04 -0700",
+	"02 Jan 06 15:04 MST",
+	"Mon, 2 January 2006 15:04:05 -0700",
+	"Mon, 2 January 2006 15:04:05 MST",
+	"Monday, 2 January 2006 15:04:05 -0700",
+	"Monday, 02-Jan-06 15:04:05 MST",
+	"Mon, 2 Jan 2006",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// zoneOffsets resolves the zone abbreviations allowed by RFC 822. Dates are
+// parsed in UTC, where time.Parse reports every abbreviation with a zero
+// offset, so dates in any other zone are treated as unparseable rather than
+// being off by hours.
+var zoneOffsets = map[string]int{
+	"UTC": 0,
+	"GMT": 0,
+	"EST": -5 * 60 * 60,
+	"EDT": -4 * 60 * 60,
+	"CST": -6 * 60 * 60,
+	"CDT": -5 * 60 * 60,
+	"MST": -7 * 60 * 60,
+	"MDT": -6 * 60 * 60,
+	"PST": -8 * 60 * 60,
+	"PDT": -7 * 60 * 60,
+}
+
+// parsePubDate parses a publication date in any of the known layouts and
+// returns it in UTC.
+func parsePubDate(value string) (time.Time, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	// time.Parse rejects zone abbreviations shorter than three letters, which
+	// rules out the RFC 822 "UT".
+	if strings.HasSuffix(value, " UT") {
+		value = strings.TrimSuffix(value, "UT") + "GMT"
+	}
+
+	for _, layout := range pubDateLayouts {
+		t, err := time.ParseInLocation(layout, value, time.UTC)
+		if err != nil {
+			continue
+		}
+
+		name, offset := t.Zone()
+		if name != "" && offset == 0 {
+			knownOffset, ok := zoneOffsets[name]
+			if !ok {
+				return time.Time{}, false
+			}
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, knownOffset))
+		}
+
+		return t.UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// normalizePubDate returns the publication date of an item. Items without a
+// date, or with a date in an unknown layout, fall back to firstSeen. The
+// second return value is false only if a date was present but unparseable.
+func normalizePubDate(value string, firstSeen time.Time) (time.Time, bool) {
+	if strings.TrimSpace(value) == "" {
+		return firstSeen, true
+	}
+
+	t, ok := parsePubDate(value)
+	if !ok {
+		return firstSeen, false
+	}
+
+	return t, true
+}
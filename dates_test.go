@@ -0,0 +1,173 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePubDate(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 -0700",
+			want:   time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 GMT",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 EST",
+			want:   time.Date(2006, 1, 2, 20, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 PDT",
+			want:   time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 2 Jan 2006 15:04 +0200",
+			want:   time.Date(2006, 1, 2, 13, 4, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "  Mon,  2 Jan 2006\n 15:04:05 +0000 ",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2 Jan 2006 15:04:05 +0000",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2006-01-02T15:04:05Z",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2006-01-02T15:04:05.123+01:00",
+			want:   time.Date(2006, 1, 2, 14, 4, 5, 123000000, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2006-01-02T15:04:05+0100",
+			want:   time.Date(2006, 1, 2, 14, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2006-01-02 15:04:05",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "2006-01-02",
+			want:   time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon Jan  2 15:04:05 2006",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 UT",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Mon, 02 Jan 2006 15:04:05 UTC",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			value:  "Monday, 02-Jan-06 15:04:05 GMT",
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{value: "Tue, 10 Jun 2003 04:00:00 CEST", wantOK: false},
+		{value: "Tue, 10 Jun 2003 04:00:00 BST", wantOK: false},
+		{value: "Tue, 10 Jun 2003 04:00:00 IST", wantOK: false},
+		{value: "Tue, 10 Jun 2003 04:00:00 AEST", wantOK: false},
+		{value: "2003-06-10 04:00:00 CEST", wantOK: false},
+		{value: "", wantOK: false},
+		{value: "   ", wantOK: false},
+		{value: "yesterday", wantOK: false},
+		{value: "2006-13-45", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parsePubDate(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parsePubDate(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePubDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			if ok && got.Location() != time.UTC {
+				t.Errorf("parsePubDate(%q) location = %v, want UTC", tt.value, got.Location())
+			}
+		})
+	}
+}
+
+func TestNormalizePubDate(t *testing.T) {
+	firstSeen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "valid date",
+			value:  "Wed, 01 May 2024 08:30:00 +0000",
+			want:   time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "missing date falls back to first seen",
+			value:  "",
+			want:   firstSeen,
+			wantOK: true,
+		},
+		{
+			name:   "blank date falls back to first seen",
+			value:  " \n ",
+			want:   firstSeen,
+			wantOK: true,
+		},
+		{
+			name:   "date in an unknown zone falls back to first seen",
+			value:  "Wed, 01 May 2024 08:30:00 CEST",
+			want:   firstSeen,
+			wantOK: false,
+		},
+		{
+			name:   "unparseable date falls back to first seen",
+			value:  "sometime last week",
+			want:   firstSeen,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normalizePubDate(tt.value, firstSeen)
+			if ok != tt.wantOK {
+				t.Errorf("normalizePubDate(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("normalizePubDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
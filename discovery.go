@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var errNoFeedFound = errors.New("no feed found")
+
+// feedAccept is sent when it is not known yet whether a URL points to a feed
+// or to a web page.
+const feedAccept = "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, text/html;q=0.8, */*;q=0.5"
+
+// feedLinkTypes maps the types of <link rel="alternate"> elements that point
+// to feeds to the format of the feed.
+var feedLinkTypes = map[string]string{
+	"application/atom+xml":  "atom",
+	"application/feed+json": "json",
+	"application/rdf+xml":   "rdf",
+	"application/rss+xml":   "rss",
+}
+
+// commonFeedPaths are tried, relative to the site root, if a page does not
+// link to any feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/feed.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// discoveryTimeout bounds the time spent looking for the feeds of a URL,
+// which happens while the client waits for its feed to be created.
+const discoveryTimeout = 15 * time.Second
+
+// feedCandidate is a feed found while looking for the feeds of a URL.
+type feedCandidate struct {
+	URL    string
+	Title  string
+	Format string
+}
+
+// discoverFeeds returns the feeds available at pageURL. If the URL is a feed
+// itself it is the only candidate. If it is a web page, the feeds it links to
+// are returned, or the first feed found at one of the common feed paths.
+func discoverFeeds(ctx context.Context, fetcher *feedFetcher, pageURL string) ([]feedCandidate, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	data, res, err := fetcher.get(ctx, pageURL, feedAccept, fetcher.config.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	doc, err := parseFeed(data, contentType)
+	if err == nil {
+		return []feedCandidate{{URL: pageURL, Title: doc.Title, Format: doc.Format}}, nil
+	}
+
+	if !isHTML(data, contentType) {
+		return nil, err
+	}
+
+	candidates := htmlFeedLinks(data, res.Request.URL)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		candidateURL := res.Request.URL.ResolveReference(&url.URL{Path: path}).String()
+
+		data, res, err := fetcher.get(ctx, candidateURL, feedAccept, fetcher.config.MaxBodySize)
+		if err != nil {
+			continue
+		}
+
+		doc, err := parseFeed(data, res.Header.Get("Content-Type"))
+		if err != nil {
+			continue
+		}
+
+		return []feedCandidate{{URL: candidateURL, Title: doc.Title, Format: doc.Format}}, nil
+	}
+
+	return nil, errNoFeedFound
+}
+
+func isHTML(data []byte, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		return true
+	}
+
+	return strings.HasPrefix(http.DetectContentType(data), "text/html")
+}
+
+// htmlFeedLinks returns the feeds a web page links to with
+// <link rel="alternate">, resolved against the page URL or its <base>.
+func htmlFeedLinks(data []byte, base *url.URL) []feedCandidate {
+	candidates := make([]feedCandidate, 0)
+	seen := map[string]bool{}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		attrs := map[string]string{}
+		for _, attr := range token.Attr {
+			attrs[attr.Key] = strings.TrimSpace(attr.Val)
+		}
+
+		if token.Data == "base" && attrs["href"] != "" {
+			if ref, err := url.Parse(attrs["href"]); err == nil {
+				base = base.ResolveReference(ref)
+			}
+			continue
+		}
+
+		if token.Data != "link" || !containsString(strings.Fields(strings.ToLower(attrs["rel"])), "alternate") {
+			continue
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(attrs["type"])
+		format, ok := feedLinkTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		feedURL, ok := resolveURL(attrs["href"], base)
+		if !ok || strings.HasPrefix(feedURL, "mailto:") || seen[feedURL] {
+			continue
+		}
+		seen[feedURL] = true
+
+		candidates = append(candidates, feedCandidate{
+			URL:    feedURL,
+			Title:  attrs["title"],
+			Format: format,
+		})
+	}
+
+	return candidates
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var (
+	errInvalidFeedURL = errors.New("invalid feed url")
+	errPrivateFeedURL = errors.New("feed url points to a private network")
+)
+
+// canonicalFeedURL validates a feed URL entered by a user and returns it in
+// canonical form: lower case scheme and host, no default port, no fragment
+// and "/" as the path of a bare host. URLs without a scheme are taken to be
+// https.
+func canonicalFeedURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: url is empty", errInvalidFeedURL)
+	}
+
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidFeedURL, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: scheme %q is not allowed", errInvalidFeedURL, u.Scheme)
+	}
+
+	if u.User != nil {
+		return "", fmt.Errorf("%w: credentials are not allowed", errInvalidFeedURL)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", fmt.Errorf("%w: host is missing", errInvalidFeedURL)
+	}
+
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	return u.String(), nil
+}
+
+// equivalentFeedURLs returns the variants of a canonical feed URL that are
+// considered the same feed: with either scheme and with or without a
+// trailing slash.
+func equivalentFeedURLs(feedURL string) []string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return []string{feedURL}
+	}
+
+	paths := []string{u.Path}
+	if strings.HasSuffix(u.Path, "/") {
+		paths = append(paths, strings.TrimSuffix(u.Path, "/"))
+	} else {
+		paths = append(paths, u.Path+"/")
+	}
+
+	urls := make([]string, 0, 4)
+	for _, scheme := range []string{"https", "http"} {
+		for _, path := range paths {
+			variant := *u
+			variant.Scheme = scheme
+			variant.Path = path
+			variant.RawPath = ""
+			urls = append(urls, variant.String())
+		}
+	}
+
+	return urls
+}
+
+// validateURL canonicalizes a user supplied feed URL and, unless private
+// networks are allowed, makes sure its host only resolves to public or
+// allow-listed addresses. The fetcher checks the addresses again when it
+// connects, this only rejects such URLs early.
+func (f *feedFetcher) validateURL(ctx context.Context, raw string) (string, error) {
+	feedURL, err := canonicalFeedURL(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if f.config.AllowPrivateNetworks {
+		return feedURL, nil
+	}
+
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidFeedURL, err)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidFeedURL, err)
+	}
+
+	for _, addr := range addrs {
+		if !f.config.allowsIP(addr.IP) {
+			return "", fmt.Errorf("%w: %s resolves to %s", errPrivateFeedURL, u.Hostname(), addr.IP)
+		}
+	}
+
+	return feedURL, nil
+}
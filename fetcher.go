@@ -0,0 +1,281 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	errResponseTooLarge = errors.New("response body too large")
+	errNotAnImage       = errors.New("response is not an image")
+)
+
+// statusCodeError is returned by the fetcher for any unexpected response status.
+type statusCodeError struct {
+	StatusCode int
+}
+
+func (e statusCodeError) Error() string {
+	return fmt.Sprintf("got status code %d", e.StatusCode)
+}
+
+// fetchResult is the outcome of a conditional fetch. Doc is only set if the
+// feed changed since the validators ETag and LastModified were issued.
+// PermanentURL is set if the feed was reached only through permanent
+// redirects and should be fetched from there from now on.
+type fetchResult struct {
+	Doc          feedDocument
+	NotModified  bool
+	ETag         string
+	LastModified string
+	PermanentURL string
+}
+
+type fetcherConfig struct {
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	Timeout        time.Duration
+	MaxBodySize    int64
+	MaxIconSize    int64
+	UserAgent      string
+	// AllowPrivateNetworks disables the check that feeds are only fetched
+	// from public addresses. AllowedNetworks exempts only the given ranges,
+	// e.g. for feeds of a self-hosted deployment.
+	AllowPrivateNetworks bool
+	AllowedNetworks      []*net.IPNet
+}
+
+func fetcherConfigFromEnv() (fetcherConfig, error) {
+	connectTimeout, err := envDuration("FETCH_CONNECT_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	readTimeout, err := envDuration("FETCH_READ_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	timeout, err := envDuration("FETCH_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	maxBodySize, err := envInt("FETCH_MAX_BODY_BYTES", 10<<20)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	maxIconSize, err := envInt("FETCH_MAX_ICON_BYTES", 256<<10)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	allowPrivateNetworks, err := envBool("FETCH_ALLOW_PRIVATE_NETWORKS", false)
+	if err != nil {
+		return fetcherConfig{}, err
+	}
+
+	allowedNetworks, err := parseNetworks(os.Getenv("FETCH_ALLOWED_NETWORKS"))
+	if err != nil {
+		return fetcherConfig{}, fmt.Errorf("invalid FETCH_ALLOWED_NETWORKS: %w", err)
+	}
+
+	return fetcherConfig{
+		ConnectTimeout: connectTimeout,
+		ReadTimeout:    readTimeout,
+		Timeout:        timeout,
+		MaxBodySize:    int64(maxBodySize),
+		MaxIconSize:    int64(maxIconSize),
+		UserAgent:      envString("FETCH_USER_AGENT", "boot.dev-aggregator/1.0 (+https://github.com/timokae/boot.dev-aggregator)"),
+
+		AllowPrivateNetworks: allowPrivateNetworks,
+		AllowedNetworks:      allowedNetworks,
+	}, nil
+}
+
+type feedFetcher struct {
+	config fetcherConfig
+	client *http.Client
+}
+
+func newFeedFetcher(config fetcherConfig) *feedFetcher {
+	dialer := &net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   config.dialControl,
+	}
+
+	// No proxy is used: a proxy resolves and connects to the target itself,
+	// which would bypass the address check of the dialer.
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.ConnectTimeout,
+		ResponseHeaderTimeout: config.ReadTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		// Compression is handled by the fetcher so that the body size limit
+		// applies to the decompressed body.
+		DisableCompression: true,
+	}
+
+	return &feedFetcher{
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+		},
+	}
+}
+
+func (f *feedFetcher) fetch(ctx context.Context, url string, etag string, lastModified string) (fetchResult, error) {
+	log.Printf("Fetching %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fetchResult{}, err
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	permanentURL := ""
+	permanent := true
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		status := req.Response.StatusCode
+		permanent = permanent && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect)
+		if permanent {
+			permanentURL = req.URL.String()
+		}
+
+		return nil
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fetchResult{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return fetchResult{
+			NotModified:  true,
+			ETag:         etag,
+			LastModified: lastModified,
+			PermanentURL: permanentURL,
+		}, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fetchResult{}, statusCodeError{StatusCode: res.StatusCode}
+	}
+
+	data, err := f.readBody(res, f.config.MaxBodySize)
+	if err != nil {
+		return fetchResult{}, err
+	}
+
+	doc, err := parseFeed(data, res.Header.Get("Content-Type"))
+	if err != nil {
+		return fetchResult{}, err
+	}
+
+	return fetchResult{
+		Doc:          doc,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		PermanentURL: permanentURL,
+	}, nil
+}
+
+// get downloads a document of at most maxSize bytes, following redirects.
+// The returned response is closed, but its headers and final request URL
+// can still be inspected.
+func (f *feedFetcher) get(ctx context.Context, url string, accept string, maxSize int64) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept", accept)
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, statusCodeError{StatusCode: res.StatusCode}
+	}
+
+	data, err := f.readBody(res, maxSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, res, nil
+}
+
+// fetchImage downloads an image of at most the configured icon size. The
+// content type is sniffed from the data, so that only raster images are
+// accepted regardless of what the server claims.
+func (f *feedFetcher) fetchImage(ctx context.Context, url string) ([]byte, string, error) {
+	data, _, err := f.get(ctx, url, "image/*", f.config.MaxIconSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("%w: %s", errNotAnImage, contentType)
+	}
+
+	return data, contentType, nil
+}
+
+// readBody reads the decompressed response body, failing if it is larger
+// than maxSize.
+func (f *feedFetcher) readBody(res *http.Response, maxSize int64) ([]byte, error) {
+	var body io.Reader = res.Body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+
+		body = gzipReader
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", errResponseTooLarge, maxSize)
+	}
+
+	return data, nil
+}
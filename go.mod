@@ -8,3 +8,5 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 )
+
+require golang.org/x/net v0.35.0
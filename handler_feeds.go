@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,14 +28,64 @@ func (cfg *apiConfig) handlerFeedsCreate(w http.ResponseWriter, r *http.Request,
 	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not decode parameter")
+		return
+	}
+
+	feedURL, err := cfg.Fetcher.validateURL(r.Context(), params.Url)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Could not add feed: %v", err))
+		return
+	}
+
+	feed, err := cfg.DB.GetFeedByUrls(r.Context(), equivalentFeedURLs(feedURL))
+	if err == nil {
+		cfg.followExistingFeed(w, r, user, feed)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Println(err)
+		respondWithError(w, http.StatusInternalServerError, "Could not look up feed")
+		return
+	}
+
+	// Users often paste the URL of a site instead of its feed. If the site
+	// has several feeds the client has to pick one of them.
+	candidates, err := discoverFeeds(r.Context(), cfg.Fetcher, feedURL)
+	if err != nil {
+		log.Println(err)
+		respondWithError(w, http.StatusBadRequest, "Could not find a feed at the given url")
+		return
 	}
 
-	feed, err := cfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
+	if len(candidates) > 1 {
+		respondWithJSON(w, http.StatusMultipleChoices, struct {
+			Candidates []FeedCandidate `json:"candidates"`
+		}{
+			Candidates: feedCandidatesToFeedCandidates(candidates),
+		})
+		return
+	}
+
+	if candidates[0].URL != feedURL {
+		feedURL, err = cfg.Fetcher.validateURL(r.Context(), candidates[0].URL)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Could not add discovered feed: %v", err))
+			return
+		}
+
+		feed, err = cfg.DB.GetFeedByUrls(r.Context(), equivalentFeedURLs(feedURL))
+		if err == nil {
+			cfg.followExistingFeed(w, r, user, feed)
+			return
+		}
+	}
+
+	feed, err = cfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
-		Name:      params.Name,
-		Url:       params.Url,
+		Name:      sql.NullString{String: params.Name, Valid: params.Name != ""},
+		Url:       feedURL,
 		UserID:    user.ID,
 	})
 	if err != nil {
@@ -60,6 +116,31 @@ func (cfg *apiConfig) handlerFeedsCreate(w http.ResponseWriter, r *http.Request,
 	})
 }
 
+// followExistingFeed responds to the creation of a feed that already exists
+// under an equivalent URL by following it, if the user does not already.
+func (cfg *apiConfig) followExistingFeed(w http.ResponseWriter, r *http.Request, user database.User, feed database.Feed) {
+	feedFollow, err := cfg.DB.UpsertFeedFollow(r.Context(), database.UpsertFeedFollowParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Println(err)
+		respondWithError(w, http.StatusInternalServerError, "Could not create feed follow")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Feed       Feed       `json:"feed"`
+		FeedFollow FeedFollow `json:"feed_follow"`
+	}{
+		Feed:       databaseFeedToFeed(feed),
+		FeedFollow: databaseFeedFollowToFeedFollow(feedFollow),
+	})
+}
+
 func (cfg *apiConfig) handlerFeedsGet(w http.ResponseWriter, r *http.Request) {
 	feeds, err := cfg.DB.GetFeeds(r.Context())
 	if err != nil {
@@ -70,3 +151,189 @@ func (cfg *apiConfig) handlerFeedsGet(w http.ResponseWriter, r *http.Request) {
 
 	respondWithJSON(w, http.StatusOK, databaseFeedsToFeeds(feeds))
 }
+
+func (cfg *apiConfig) handlerFeedsEnable(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Url string `json:"url"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid feed id")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Could not decode parameters")
+		return
+	}
+
+	feed, err := cfg.DB.GetFeed(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Could not find feed")
+		return
+	}
+
+	if feed.UserID != user.ID {
+		respondWithError(w, http.StatusForbidden, "Only the owner can enable a feed")
+		return
+	}
+
+	if params.Url != "" {
+		params.Url, err = cfg.Fetcher.validateURL(r.Context(), params.Url)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Could not enable feed: %v", err))
+			return
+		}
+
+		// A feed that moved to the URL of another feed is merged into it,
+		// the same way as a feed that is redirected there.
+		existing, err := cfg.DB.GetOtherFeedByUrls(r.Context(), database.GetOtherFeedByUrlsParams{
+			Urls: equivalentFeedURLs(params.Url),
+			ID:   feed.ID,
+		})
+		if err == nil {
+			merged, err := moveFeedURL(r.Context(), cfg.Conn, cfg.DB, feed, existing.Url)
+			if err != nil {
+				log.Println(err)
+				respondWithError(w, http.StatusInternalServerError, "Could not enable feed")
+				return
+			}
+
+			respondWithJSON(w, http.StatusOK, databaseFeedToFeed(merged))
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Println(err)
+			respondWithError(w, http.StatusInternalServerError, "Could not enable feed")
+			return
+		}
+	}
+
+	feed, err = cfg.DB.EnableFeed(r.Context(), database.EnableFeedParams{
+		ID:  feed.ID,
+		Url: sql.NullString{String: params.Url, Valid: params.Url != ""},
+	})
+	if err != nil {
+		log.Println(err)
+		respondWithError(w, http.StatusBadRequest, "Could not enable feed")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseFeedToFeed(feed))
+}
+
+func (cfg *apiConfig) handlerFeedsIcon(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid feed id")
+		return
+	}
+
+	icon, err := cfg.DB.GetFeedIcon(r.Context(), id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println(err)
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed icon")
+		return
+	}
+
+	// Feeds without a usable icon only have their failed check recorded.
+	if err != nil || !icon.ContentType.Valid {
+		respondWithError(w, http.StatusNotFound, "Feed has no icon")
+		return
+	}
+
+	w.Header().Set("Content-Type", icon.ContentType.String)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", `"`+icon.Hash.String+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent answers conditional requests based on the headers above.
+	http.ServeContent(w, r, "", icon.UpdatedAt, bytes.NewReader(icon.Data))
+}
+
+// previewSampleSize is the number of items returned by a feed preview.
+const previewSampleSize = 5
+
+func (cfg *apiConfig) handlerFeedsPreview(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Url string `json:"url"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Could not decode parameters")
+		return
+	}
+
+	feedURL, err := cfg.Fetcher.validateURL(r.Context(), params.Url)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Could not preview feed: %v", err))
+		return
+	}
+
+	result, err := cfg.Fetcher.fetch(r.Context(), feedURL, "", "")
+	if err != nil {
+		log.Println(err)
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Could not fetch feed: %v", err))
+		return
+	}
+
+	doc := result.Doc
+	metadata := normalizeFeedMetadata(doc, feedURL)
+
+	warnings := make([]string, 0)
+	if result.PermanentURL != "" {
+		warnings = append(warnings, fmt.Sprintf("feed moved permanently to %s", result.PermanentURL))
+	}
+	if len(doc.Items) == 0 {
+		warnings = append(warnings, "feed has no items")
+	}
+	if doc.Updated != "" && !metadata.HasBuildDate {
+		warnings = append(warnings, fmt.Sprintf("could not parse feed date %q", doc.Updated))
+	}
+
+	firstSeen := time.Now().UTC()
+	items := make([]PreviewItem, 0, min(len(doc.Items), previewSampleSize))
+	for i, item := range doc.Items {
+		post := normalizeItem(item, feedURL, firstSeen)
+
+		if !post.DateParsed {
+			warnings = append(warnings, fmt.Sprintf("item %d: could not parse date %q", i+1, post.PubDate))
+		}
+		if strings.TrimSpace(post.PubDate) == "" {
+			warnings = append(warnings, fmt.Sprintf("item %d: no date, the time it is first seen is used", i+1))
+		}
+		if strings.TrimSpace(post.Guid) == "" {
+			warnings = append(warnings, fmt.Sprintf("item %d: no guid, identified by %s", i+1, post.Identity))
+		}
+
+		if len(items) < previewSampleSize {
+			items = append(items, normalizedItemToPreviewItem(post))
+		}
+	}
+
+	var lastBuildDate *time.Time
+	if metadata.HasBuildDate {
+		lastBuildDate = &metadata.LastBuildDate
+	}
+
+	respondWithJSON(w, http.StatusOK, FeedPreview{
+		Url:           feedURL,
+		Format:        doc.Format,
+		Title:         metadata.Title,
+		Description:   metadata.Description,
+		SiteURL:       metadata.SiteURL,
+		IconURL:       metadata.IconURL,
+		Language:      metadata.Language,
+		LastBuildDate: lastBuildDate,
+		ItemCount:     len(doc.Items),
+		Items:         items,
+		Warnings:      warnings,
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -42,14 +43,54 @@ func (cfg *apiConfig) handlerUserGet(w http.ResponseWriter, r *http.Request, use
 }
 
 func (cfg *apiConfig) handlerGetPostsForUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	contentMode := r.URL.Query().Get("content")
+	if contentMode == "" {
+		contentMode = "summary"
+	}
+	if contentMode != "summary" && contentMode != "full" {
+		respondWithError(w, http.StatusBadRequest, "content must be either summary or full")
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	author := r.URL.Query().Get("author")
+
 	posts, err := cfg.DB.GetPostsForUser(r.Context(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  10,
+		UserID:   user.ID,
+		Category: sql.NullString{String: category, Valid: category != ""},
+		Author:   sql.NullString{String: author, Valid: author != ""},
+		Limit:    10,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not get posts for user")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, databasePostsToPosts(posts))
+	postIDs := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	enclosures, err := cfg.DB.GetEnclosuresForPosts(r.Context(), postIDs)
+	if err != nil {
+		log.Println(err)
+		respondWithError(w, http.StatusInternalServerError, "Could not get enclosures for posts")
+		return
+	}
+
+	postsToReturn := databasePostsToPosts(posts)
+	for i := range postsToReturn {
+		for _, enclosure := range enclosures {
+			if enclosure.PostID == postsToReturn[i].ID {
+				postsToReturn[i].Enclosures = append(postsToReturn[i].Enclosures, databaseEnclosureToEnclosure(enclosure))
+			}
+		}
+	}
+	if contentMode == "summary" {
+		for i := range postsToReturn {
+			postsToReturn[i].Content = nil
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, postsToReturn)
 }
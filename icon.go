@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/timokae/boot.dev-aggregator/internal/database"
+)
+
+// iconRefreshInterval is how long a stored icon is used before it is
+// downloaded again.
+const iconRefreshInterval = 7 * 24 * time.Hour
+
+// iconCandidates returns the URLs an icon of the feed is looked for at, in
+// order of preference: the image announced by the feed itself and the
+// favicon of the site, or of the feed's host if the site is unknown.
+func iconCandidates(iconURL string, siteURL string, feedURL string) []string {
+	candidates := make([]string, 0, 2)
+	if iconURL != "" {
+		candidates = append(candidates, iconURL)
+	}
+
+	base := itemBaseURL(siteURL, feedURL)
+	if base != nil && (base.Scheme == "http" || base.Scheme == "https") {
+		favicon := base.ResolveReference(&url.URL{Path: "/favicon.ico"})
+		candidates = append(candidates, favicon.String())
+	}
+
+	return candidates
+}
+
+// refreshFeedIcon downloads and stores the icon of a feed, unless it was
+// checked recently and the feed still announces the same icon. Failed checks
+// are recorded as well, keeping a previously stored icon, so that feeds
+// without a usable icon are not checked on every fetch.
+func (s *scraper) refreshFeedIcon(ctx context.Context, feed database.Feed, iconURL string, siteURL string) {
+	icon, err := s.db.GetFeedIcon(ctx, feed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("error getting feed icon", err)
+		return
+	}
+
+	if err == nil && time.Since(icon.CheckedAt) < iconRefreshInterval && icon.AnnouncedUrl.String == iconURL {
+		return
+	}
+
+	announcedURL := sql.NullString{String: iconURL, Valid: iconURL != ""}
+
+	for _, candidate := range iconCandidates(iconURL, siteURL, feed.Url) {
+		data, contentType, err := s.fetcher.fetchImage(ctx, candidate)
+		if err != nil {
+			log.Printf("could not fetch icon %s of feed %s: %v", candidate, feed.Url, err)
+			continue
+		}
+
+		hash := sha256.Sum256(data)
+		err = s.db.UpsertFeedIcon(ctx, database.UpsertFeedIconParams{
+			FeedID:       feed.ID,
+			CreatedAt:    time.Now().UTC(),
+			UpdatedAt:    time.Now().UTC(),
+			CheckedAt:    time.Now().UTC(),
+			Url:          sql.NullString{String: candidate, Valid: true},
+			ContentType:  sql.NullString{String: contentType, Valid: true},
+			Hash:         sql.NullString{String: hex.EncodeToString(hash[:]), Valid: true},
+			Data:         data,
+			AnnouncedUrl: announcedURL,
+		})
+		if err != nil {
+			log.Println("error storing feed icon", err)
+		}
+		return
+	}
+
+	err = s.db.MarkFeedIconChecked(ctx, database.MarkFeedIconCheckedParams{
+		FeedID:       feed.ID,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+		CheckedAt:    time.Now().UTC(),
+		AnnouncedUrl: announcedURL,
+	})
+	if err != nil {
+		log.Println("error marking feed icon as checked", err)
+	}
+}
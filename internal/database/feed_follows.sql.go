@@ -109,3 +109,65 @@ func (q *Queries) GetFeedFollowsOfUser(ctx context.Context, userID uuid.UUID) ([
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET
+  feed_id = $1,
+  updated_at = NOW()
+WHERE feed_id = $2
+  AND user_id NOT IN (
+    SELECT user_id FROM feed_follows WHERE feed_id = $1
+  )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const upsertFeedFollow = `-- name: UpsertFeedFollow :one
+INSERT INTO feed_follows (
+  id,
+  user_id,
+  feed_id,
+  created_at,
+  updated_at
+)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id, user_id) DO UPDATE
+SET updated_at = feed_follows.updated_at
+RETURNING id, feed_id, user_id, created_at, updated_at
+`
+
+type UpsertFeedFollowParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpsertFeedFollow(ctx context.Context, arg UpsertFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeedFollow,
+		arg.ID,
+		arg.UserID,
+		arg.FeedID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -7,11 +7,84 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimNextFeedsToFetch = `-- name: ClaimNextFeedsToFetch :many
+UPDATE feeds
+SET
+  claimed_until = $1,
+  updated_at = NOW()
+WHERE id IN (
+  SELECT id FROM feeds
+  WHERE disabled_at IS NULL
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+  ORDER BY next_fetch_at ASC NULLS FIRST, poll_interval_seconds ASC NULLS LAST, last_fetched_at ASC NULLS FIRST
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds
+`
+
+type ClaimNextFeedsToFetchParams struct {
+	ClaimedUntil sql.NullTime
+	Limit        int32
+}
+
+func (q *Queries) ClaimNextFeedsToFetch(ctx context.Context, arg ClaimNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimNextFeedsToFetch, arg.ClaimedUntil, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ClaimedUntil,
+			&i.Title,
+			&i.Description,
+			&i.SiteUrl,
+			&i.IconUrl,
+			&i.Language,
+			&i.LastBuildDate,
+			&i.UpdateIntervalSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.PollIntervalSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (
   id,
@@ -22,14 +95,14 @@ INSERT INTO feeds (
   user_id
 )
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds
 `
 
 type CreateFeedParams struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string
+	Name      sql.NullString
 	Url       string
 	UserID    uuid.UUID
 }
@@ -52,12 +125,106 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const disableFeed = `-- name: DisableFeed :exec
+UPDATE feeds
+SET
+  disabled_at = NOW(),
+  updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, id)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET
+  url = COALESCE($1, url),
+  etag = CASE WHEN $1 <> url THEN NULL ELSE etag END,
+  last_modified = CASE WHEN $1 <> url THEN NULL ELSE last_modified END,
+  last_error = CASE WHEN $1 <> url THEN NULL ELSE last_error END,
+  last_error_at = CASE WHEN $1 <> url THEN NULL ELSE last_error_at END,
+  disabled_at = NULL,
+  consecutive_failures = 0,
+  next_fetch_at = NULL,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds
+`
+
+type EnableFeedParams struct {
+	Url sql.NullString
+	ID  uuid.UUID
+}
+
+func (q *Queries) EnableFeed(ctx context.Context, arg EnableFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, arg.Url, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
 	)
 	return i, err
 }
 
 const getFeed = `-- name: GetFeed :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE id = $1 LIMIT 1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds FROM feeds WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -71,12 +238,70 @@ func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
+	)
+	return i, err
+}
+
+const getFeedByUrls = `-- name: GetFeedByUrls :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds FROM feeds
+WHERE url = ANY($1::text[])
+ORDER BY created_at
+LIMIT 1
+`
+
+func (q *Queries) GetFeedByUrls(ctx context.Context, urls []string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByUrls, pq.Array(urls))
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds
 FROM feeds
 `
 
@@ -97,6 +322,24 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ClaimedUntil,
+			&i.Title,
+			&i.Description,
+			&i.SiteUrl,
+			&i.IconUrl,
+			&i.Language,
+			&i.LastBuildDate,
+			&i.UpdateIntervalSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.PollIntervalSeconds,
 		); err != nil {
 			return nil, err
 		}
@@ -111,54 +354,93 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1
+const getOtherFeedByUrls = `-- name: GetOtherFeedByUrls :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds FROM feeds
+WHERE url = ANY($1::text[]) AND id <> $2
+ORDER BY created_at
+LIMIT 1
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Feed
-	for rows.Next() {
-		var i Feed
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.Url,
-			&i.UserID,
-			&i.LastFetchedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type GetOtherFeedByUrlsParams struct {
+	Urls []string
+	ID   uuid.UUID
+}
+
+func (q *Queries) GetOtherFeedByUrls(ctx context.Context, arg GetOtherFeedByUrlsParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getOtherFeedByUrls, pq.Array(arg.Urls), arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
+	)
+	return i, err
+}
+
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET
+  consecutive_failures = consecutive_failures + 1,
+  last_error = $2,
+  last_error_at = NOW(),
+  next_fetch_at = $3,
+  claimed_until = NULL,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type MarkFeedFetchFailedParams struct {
+	ID          uuid.UUID
+	LastError   sql.NullString
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed, arg.ID, arg.LastError, arg.NextFetchAt)
+	return err
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
 SET
   last_fetched_at = NOW(),
+  next_fetch_at = $2,
+  consecutive_failures = 0,
+  claimed_until = NULL,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, consecutive_failures, last_error, last_error_at, next_fetch_at, disabled_at, claimed_until, title, description, site_url, icon_url, language, last_build_date, update_interval_seconds, skip_hours, skip_days, poll_interval_seconds
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type MarkFeedFetchedParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetched, arg.ID, arg.NextFetchAt)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -168,6 +450,127 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ClaimedUntil,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+		&i.Language,
+		&i.LastBuildDate,
+		&i.UpdateIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.PollIntervalSeconds,
 	)
 	return i, err
 }
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET
+  etag = $2,
+  last_modified = $3,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET
+  title = $2,
+  description = $3,
+  site_url = $4,
+  icon_url = $5,
+  language = $6,
+  last_build_date = $7,
+  update_interval_seconds = $8,
+  skip_hours = $9,
+  skip_days = $10,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID                    uuid.UUID
+	Title                 sql.NullString
+	Description           sql.NullString
+	SiteUrl               sql.NullString
+	IconUrl               sql.NullString
+	Language              sql.NullString
+	LastBuildDate         sql.NullTime
+	UpdateIntervalSeconds sql.NullInt32
+	SkipHours             []int32
+	SkipDays              []int32
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.SiteUrl,
+		arg.IconUrl,
+		arg.Language,
+		arg.LastBuildDate,
+		arg.UpdateIntervalSeconds,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+	)
+	return err
+}
+
+const updateFeedPollInterval = `-- name: UpdateFeedPollInterval :exec
+UPDATE feeds
+SET
+  poll_interval_seconds = $2,
+  next_fetch_at = $3,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedPollIntervalParams struct {
+	ID                  uuid.UUID
+	PollIntervalSeconds sql.NullInt32
+	NextFetchAt         sql.NullTime
+}
+
+func (q *Queries) UpdateFeedPollInterval(ctx context.Context, arg UpdateFeedPollIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedPollInterval, arg.ID, arg.PollIntervalSeconds, arg.NextFetchAt)
+	return err
+}
+
+const updateFeedUrl = `-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET
+  url = $2,
+  updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedUrlParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedUrl(ctx context.Context, arg UpdateFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedUrl, arg.ID, arg.Url)
+	return err
+}
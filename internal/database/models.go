@@ -11,14 +11,46 @@ import (
 	"github.com/google/uuid"
 )
 
+type Enclosure struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	Season          sql.NullInt32
+	ImageUrl        sql.NullString
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Name                  sql.NullString
+	Url                   string
+	UserID                uuid.UUID
+	LastFetchedAt         sql.NullTime
+	Etag                  sql.NullString
+	LastModified          sql.NullString
+	ConsecutiveFailures   int32
+	LastError             sql.NullString
+	LastErrorAt           sql.NullTime
+	NextFetchAt           sql.NullTime
+	DisabledAt            sql.NullTime
+	ClaimedUntil          sql.NullTime
+	Title                 sql.NullString
+	Description           sql.NullString
+	SiteUrl               sql.NullString
+	IconUrl               sql.NullString
+	Language              sql.NullString
+	LastBuildDate         sql.NullTime
+	UpdateIntervalSeconds sql.NullInt32
+	SkipHours             []int32
+	SkipDays              []int32
+	PollIntervalSeconds   sql.NullInt32
 }
 
 type FeedFollow struct {
@@ -29,15 +61,33 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 }
 
+type FeedIcon struct {
+	FeedID       uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CheckedAt    time.Time
+	Url          sql.NullString
+	ContentType  sql.NullString
+	Hash         sql.NullString
+	Data         []byte
+	AnnouncedUrl sql.NullString
+}
+
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Description sql.NullString
-	Url         string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               string
+	Description         sql.NullString
+	Url                 sql.NullString
+	PublishedAt         time.Time
+	FeedID              uuid.UUID
+	UnparsedPublishedAt sql.NullString
+	Guid                string
+	Content             sql.NullString
+	Excerpt             sql.NullString
+	Authors             []string
+	Categories          []string
 }
 
 type User struct {
@@ -11,74 +11,39 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (
-  id,
-  created_at,
-  updated_at,
-  title,
-  description,
-  url,
-  published_at,
-  feed_id
-)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, description, url, published_at, feed_id
-`
-
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Description sql.NullString
-	Url         string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
-}
-
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Description,
-		arg.Url,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Title,
-		&i.Description,
-		&i.Url,
-		&i.PublishedAt,
-		&i.FeedID,
-	)
-	return i, err
-}
-
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.url, posts.published_at, posts.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.description, posts.url, posts.published_at, posts.feed_id, posts.unparsed_published_at, posts.guid, posts.content, posts.excerpt, posts.authors, posts.categories FROM posts
 JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 WHERE feed_follows.user_id = $1
+  AND (
+    $2::text IS NULL
+    OR EXISTS (SELECT 1 FROM unnest(posts.categories) AS category WHERE lower(category) = lower($2))
+  )
+  AND (
+    $3::text IS NULL
+    OR EXISTS (SELECT 1 FROM unnest(posts.authors) AS author WHERE lower(author) = lower($3))
+  )
 ORDER BY posts.published_at desc
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID   uuid.UUID
+	Category sql.NullString
+	Author   sql.NullString
+	Limit    int32
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Category,
+		arg.Author,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +60,12 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Url,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.UnparsedPublishedAt,
+			&i.Guid,
+			&i.Content,
+			&i.Excerpt,
+			pq.Array(&i.Authors),
+			pq.Array(&i.Categories),
 		); err != nil {
 			return nil, err
 		}
@@ -108,3 +79,135 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getRecentPostTimes = `-- name: GetRecentPostTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetRecentPostTimesParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetRecentPostTimes(ctx context.Context, arg GetRecentPostTimesParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostTimes, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var published_at time.Time
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET
+  feed_id = $1,
+  updated_at = NOW()
+WHERE feed_id = $2
+  AND guid NOT IN (
+    SELECT guid FROM posts WHERE feed_id = $1
+  )
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (
+  id,
+  created_at,
+  updated_at,
+  title,
+  description,
+  url,
+  published_at,
+  feed_id,
+  unparsed_published_at,
+  guid,
+  content,
+  excerpt,
+  authors,
+  categories
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+  title = EXCLUDED.title,
+  description = EXCLUDED.description,
+  url = EXCLUDED.url,
+  content = EXCLUDED.content,
+  excerpt = EXCLUDED.excerpt,
+  authors = EXCLUDED.authors,
+  categories = EXCLUDED.categories,
+  updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+  OR posts.description IS DISTINCT FROM EXCLUDED.description
+  OR posts.url IS DISTINCT FROM EXCLUDED.url
+  OR posts.content IS DISTINCT FROM EXCLUDED.content
+  OR posts.authors IS DISTINCT FROM EXCLUDED.authors
+  OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted
+`
+
+type UpsertPostParams struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               string
+	Description         sql.NullString
+	Url                 sql.NullString
+	PublishedAt         time.Time
+	FeedID              uuid.UUID
+	UnparsedPublishedAt sql.NullString
+	Guid                string
+	Content             sql.NullString
+	Excerpt             sql.NullString
+	Authors             []string
+	Categories          []string
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Description,
+		arg.Url,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.UnparsedPublishedAt,
+		arg.Guid,
+		arg.Content,
+		arg.Excerpt,
+		pq.Array(arg.Authors),
+		pq.Array(arg.Categories),
+	)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"html"
+	"strings"
+)
+
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Description string           `json:"description"`
+	Icon        string           `json:"icon"`
+	Favicon     string           `json:"favicon"`
+	Language    string           `json:"language"`
+	Authors     []JSONFeedAuthor `json:"authors"`
+	Author      *JSONFeedAuthor  `json:"author"`
+	Items       []struct {
+		ID            string           `json:"id"`
+		URL           string           `json:"url"`
+		ExternalURL   string           `json:"external_url"`
+		Title         string           `json:"title"`
+		ContentHTML   string           `json:"content_html"`
+		ContentText   string           `json:"content_text"`
+		Summary       string           `json:"summary"`
+		DatePublished string           `json:"date_published"`
+		DateModified  string           `json:"date_modified"`
+		Image         string           `json:"image"`
+		Tags          []string         `json:"tags"`
+		Authors       []JSONFeedAuthor `json:"authors"`
+		Author        *JSONFeedAuthor  `json:"author"`
+		Attachments   []struct {
+			URL               string  `json:"url"`
+			MimeType          string  `json:"mime_type"`
+			SizeInBytes       int64   `json:"size_in_bytes"`
+			DurationInSeconds float64 `json:"duration_in_seconds"`
+		} `json:"attachments"`
+	} `json:"items"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// authorNames returns the names of the authors. JSON Feed 1.0 only had a
+// single author object, which 1.1 replaced with an array.
+func authorNames(authors []JSONFeedAuthor, author *JSONFeedAuthor) []string {
+	if author != nil {
+		authors = append(authors, *author)
+	}
+
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		names = append(names, a.Name)
+	}
+
+	return uniqueStrings(names)
+}
+
+func (feed JSONFeed) toFeedDocument() feedDocument {
+	icon := feed.Icon
+	if icon == "" {
+		icon = feed.Favicon
+	}
+
+	doc := feedDocument{
+		Format:      "json",
+		Title:       feed.Title,
+		Description: feed.Description,
+		Link:        strings.TrimSpace(feed.HomePageURL),
+		Language:    feed.Language,
+		Icon:        strings.TrimSpace(icon),
+		Items:       make([]feedItem, 0, len(feed.Items)),
+	}
+
+	for _, item := range feed.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		// content_text and summary are plain text, so they are escaped
+		// before they are handled like HTML.
+		content := item.ContentHTML
+		if content == "" {
+			content = html.EscapeString(item.ContentText)
+		}
+
+		description := html.EscapeString(item.Summary)
+		if description == "" {
+			description = content
+		}
+
+		enclosures := make([]feedEnclosure, 0, len(item.Attachments))
+		podcast := podcastInfo{Image: item.Image}
+		for _, attachment := range item.Attachments {
+			enclosures = append(enclosures, feedEnclosure{
+				URL:    strings.TrimSpace(attachment.URL),
+				Type:   attachment.MimeType,
+				Length: attachment.SizeInBytes,
+			})
+
+			if podcast.DurationSeconds == 0 {
+				podcast.DurationSeconds = int(attachment.DurationInSeconds)
+			}
+		}
+
+		authors := authorNames(item.Authors, item.Author)
+		if len(authors) == 0 {
+			authors = authorNames(feed.Authors, feed.Author)
+		}
+
+		doc.Items = append(doc.Items, feedItem{
+			Title:       item.Title,
+			Link:        strings.TrimSpace(link),
+			PubDate:     pubDate,
+			Guid:        item.ID,
+			Description: description,
+			Content:     content,
+			Enclosures:  enclosures,
+			Podcast:     podcast,
+			Authors:     authors,
+			Categories:  uniqueStrings(item.Tags),
+		})
+	}
+
+	return doc
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const jsonFeedFixture = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Example JSON Feed",
+  "home_page_url": "https://example.org/",
+  "feed_url": "https://example.org/feed.json",
+  "description": "Posts about Go",
+  "favicon": "https://example.org/favicon.png",
+  "language": "en-US",
+  "authors": [{"name": "Feed Author"}],
+  "items": [
+    {
+      "id": "2",
+      "url": "https://example.org/2",
+      "title": "HTML content",
+      "content_html": "<p>Hello <b>world</b></p>",
+      "summary": "Use <b> for bold & more",
+      "date_published": "2024-05-02T09:00:00Z",
+      "date_modified": "2024-05-02T10:00:00Z",
+      "tags": ["go", "Go", "feeds"],
+      "authors": [{"name": "Item Author"}, {"name": "Item Author"}]
+    },
+    {
+      "id": "1",
+      "external_url": "https://elsewhere.example.com/1",
+      "title": "Text content",
+      "content_text": "Wrap it in a <template> element",
+      "date_modified": "2024-05-01T10:00:00Z",
+      "author": {"name": "Legacy Author"},
+      "image": "https://example.org/1.jpg",
+      "attachments": [
+        {"url": " https://cdn.example.org/1.mp3 ", "mime_type": "audio/mpeg", "size_in_bytes": 4096, "duration_in_seconds": 61.5}
+      ]
+    },
+    {
+      "id": "0",
+      "content_text": "No authors"
+    }
+  ]
+}`
+
+func TestParseFeedJSONFeed(t *testing.T) {
+	doc, err := parseFeed([]byte(jsonFeedFixture), "application/feed+json")
+	if err != nil {
+		t.Fatalf("parseFeed returned error: %v", err)
+	}
+
+	if doc.Format != "json" {
+		t.Errorf("Format = %q, want %q", doc.Format, "json")
+	}
+	if doc.Title != "Example JSON Feed" || doc.Link != "https://example.org/" || doc.Language != "en-US" {
+		t.Errorf("metadata = %q, %q, %q", doc.Title, doc.Link, doc.Language)
+	}
+	if doc.Icon != "https://example.org/favicon.png" {
+		t.Errorf("Icon = %q, want the favicon", doc.Icon)
+	}
+	if len(doc.Items) != 3 {
+		t.Fatalf("got %d items, want 3", len(doc.Items))
+	}
+
+	tests := []struct {
+		name string
+		got  feedItem
+		want feedItem
+	}{
+		{
+			name: "html content with plain text summary",
+			got:  doc.Items[0],
+			want: feedItem{
+				Title:       "HTML content",
+				Link:        "https://example.org/2",
+				PubDate:     "2024-05-02T09:00:00Z",
+				Guid:        "2",
+				Description: "Use &lt;b&gt; for bold &amp; more",
+				Content:     "<p>Hello <b>world</b></p>",
+				Enclosures:  []feedEnclosure{},
+				Podcast:     podcastInfo{},
+				Authors:     []string{"Item Author"},
+				Categories:  []string{"go", "feeds"},
+			},
+		},
+		{
+			name: "plain text content with attachment and version 1.0 author",
+			got:  doc.Items[1],
+			want: feedItem{
+				Title:       "Text content",
+				Link:        "https://elsewhere.example.com/1",
+				PubDate:     "2024-05-01T10:00:00Z",
+				Guid:        "1",
+				Description: "Wrap it in a &lt;template&gt; element",
+				Content:     "Wrap it in a &lt;template&gt; element",
+				Enclosures: []feedEnclosure{
+					{URL: "https://cdn.example.org/1.mp3", Type: "audio/mpeg", Length: 4096},
+				},
+				Podcast:    podcastInfo{DurationSeconds: 61, Image: "https://example.org/1.jpg"},
+				Authors:    []string{"Legacy Author"},
+				Categories: []string{},
+			},
+		},
+		{
+			name: "item inheriting the feed authors",
+			got:  doc.Items[2],
+			want: feedItem{
+				Guid:        "0",
+				Description: "No authors",
+				Content:     "No authors",
+				Enclosures:  []feedEnclosure{},
+				Authors:     []string{"Feed Author"},
+				Categories:  []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("item = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -28,14 +32,58 @@ func main() {
 		log.Fatalln("Database connection string missing")
 	}
 
+	maxFeedFailures, err := envInt("FEED_MAX_FAILURES", 20)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	scrapeWorkers, err := envInt("SCRAPE_WORKERS", 10)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if scrapeWorkers < 1 {
+		log.Fatalln("SCRAPE_WORKERS must be at least 1")
+	}
+
+	scrapeInterval, err := envDuration("SCRAPE_INTERVAL", time.Minute)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	scrapeMinInterval, err := envDuration("SCRAPE_MIN_INTERVAL", time.Minute)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	scrapeMaxInterval, err := envDuration("SCRAPE_MAX_INTERVAL", 24*time.Hour)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if scrapeMinInterval > scrapeMaxInterval {
+		log.Fatalln("SCRAPE_MIN_INTERVAL must not be greater than SCRAPE_MAX_INTERVAL")
+	}
+
+	scrapeLease, err := envDuration("SCRAPE_LEASE", 5*time.Minute)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fetcherCfg, err := fetcherConfigFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	dbQueries := database.New(db)
+	fetcher := newFeedFetcher(fetcherCfg)
 	cfg := apiConfig{
-		DB: dbQueries,
+		Conn:    db,
+		DB:      dbQueries,
+		Fetcher: fetcher,
 	}
 
 	mux := http.NewServeMux()
@@ -53,6 +101,9 @@ func main() {
 
 	mux.HandleFunc("POST /v1/feeds", cfg.middlewareAuth(cfg.handlerFeedsCreate))
 	mux.HandleFunc("GET /v1/feeds", cfg.handlerFeedsGet)
+	mux.HandleFunc("POST /v1/feeds/preview", cfg.middlewareAuth(cfg.handlerFeedsPreview))
+	mux.HandleFunc("POST /v1/feeds/{id}/enable", cfg.middlewareAuth(cfg.handlerFeedsEnable))
+	mux.HandleFunc("GET /v1/feeds/{id}/icon", cfg.handlerFeedsIcon)
 
 	mux.HandleFunc("POST /v1/feed_follows", cfg.middlewareAuth(cfg.handlerFeedFollowsCreate))
 	mux.HandleFunc("DELETE /v1/feed_follows/{id}", cfg.middlewareAuth(cfg.handlerFeedFollowsDelete))
@@ -60,11 +111,38 @@ func main() {
 
 	mux.HandleFunc("GET /v1/posts", cfg.middlewareAuth(cfg.handlerGetPostsForUser))
 
-	go scrapeFeeds(cfg.DB, 10, time.Minute)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	feedScraper := &scraper{
+		conn:         db,
+		db:           dbQueries,
+		fetcher:      fetcher,
+		maxFailures:  maxFeedFailures,
+		workers:      scrapeWorkers,
+		interval:     scrapeInterval,
+		minInterval:  scrapeMinInterval,
+		maxInterval:  scrapeMaxInterval,
+		pollInterval: 5 * time.Second,
+		lease:        scrapeLease,
+	}
+	go feedScraper.run(ctx)
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Println("error shutting down server", err)
+		}
+	}()
 
 	log.Printf("Serving on port: %s\n", port)
 	err = server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalln(err)
 	}
 }
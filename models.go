@@ -26,29 +26,113 @@ func databaseUserToUser(user database.User) User {
 }
 
 type Feed struct {
-	ID            uuid.UUID  `json:"id"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	Name          string     `json:"name"`
-	Url           string     `json:"url"`
-	UserID        uuid.UUID  `json:"user_id"`
-	LastFetchedAt *time.Time `json:"last_fetched"`
+	ID                  uuid.UUID  `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Name                *string    `json:"name"`
+	Title               *string    `json:"title"`
+	Description         *string    `json:"description"`
+	SiteURL             *string    `json:"site_url"`
+	IconURL             *string    `json:"icon_url"`
+	Language            *string    `json:"language"`
+	LastBuildDate       *time.Time `json:"last_build_date"`
+	Url                 string     `json:"url"`
+	UserID              uuid.UUID  `json:"user_id"`
+	LastFetchedAt       *time.Time `json:"last_fetched"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	LastError           *string    `json:"last_error"`
+	LastErrorAt         *time.Time `json:"last_error_at"`
+	NextFetchAt         *time.Time `json:"next_fetch_at"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	PollIntervalSeconds *int32     `json:"poll_interval_seconds"`
 }
 
 func databaseFeedToFeed(feed database.Feed) Feed {
+	var name *string
+	if feed.Name.Valid {
+		name = &feed.Name.String
+	}
+
+	var title *string
+	if feed.Title.Valid {
+		title = &feed.Title.String
+	}
+
+	var description *string
+	if feed.Description.Valid {
+		description = &feed.Description.String
+	}
+
+	var siteURL *string
+	if feed.SiteUrl.Valid {
+		siteURL = &feed.SiteUrl.String
+	}
+
+	var iconURL *string
+	if feed.IconUrl.Valid {
+		iconURL = &feed.IconUrl.String
+	}
+
+	var language *string
+	if feed.Language.Valid {
+		language = &feed.Language.String
+	}
+
+	var lastBuildDate *time.Time
+	if feed.LastBuildDate.Valid {
+		lastBuildDate = &feed.LastBuildDate.Time
+	}
+
 	var lastFetchedAt *time.Time
 	if feed.LastFetchedAt.Valid {
 		lastFetchedAt = &feed.LastFetchedAt.Time
 	}
 
+	var lastError *string
+	if feed.LastError.Valid {
+		lastError = &feed.LastError.String
+	}
+
+	var lastErrorAt *time.Time
+	if feed.LastErrorAt.Valid {
+		lastErrorAt = &feed.LastErrorAt.Time
+	}
+
+	var nextFetchAt *time.Time
+	if feed.NextFetchAt.Valid {
+		nextFetchAt = &feed.NextFetchAt.Time
+	}
+
+	var disabledAt *time.Time
+	if feed.DisabledAt.Valid {
+		disabledAt = &feed.DisabledAt.Time
+	}
+
+	var pollIntervalSeconds *int32
+	if feed.PollIntervalSeconds.Valid {
+		pollIntervalSeconds = &feed.PollIntervalSeconds.Int32
+	}
+
 	return Feed{
-		ID:            feed.ID,
-		CreatedAt:     feed.CreatedAt,
-		UpdatedAt:     feed.UpdatedAt,
-		Name:          feed.Name,
-		Url:           feed.Url,
-		UserID:        feed.UserID,
-		LastFetchedAt: lastFetchedAt,
+		ID:                  feed.ID,
+		CreatedAt:           feed.CreatedAt,
+		UpdatedAt:           feed.UpdatedAt,
+		Name:                name,
+		Title:               title,
+		Description:         description,
+		SiteURL:             siteURL,
+		IconURL:             iconURL,
+		Language:            language,
+		LastBuildDate:       lastBuildDate,
+		Url:                 feed.Url,
+		UserID:              feed.UserID,
+		LastFetchedAt:       lastFetchedAt,
+		ConsecutiveFailures: feed.ConsecutiveFailures,
+		LastError:           lastError,
+		LastErrorAt:         lastErrorAt,
+		NextFetchAt:         nextFetchAt,
+		DisabledAt:          disabledAt,
+		PollIntervalSeconds: pollIntervalSeconds,
 	}
 }
 
@@ -62,6 +146,103 @@ func databaseFeedsToFeeds(feeds []database.Feed) []Feed {
 	return feedsToReturn
 }
 
+type FeedCandidate struct {
+	URL    string  `json:"url"`
+	Title  *string `json:"title"`
+	Format string  `json:"format"`
+}
+
+func feedCandidatesToFeedCandidates(candidates []feedCandidate) []FeedCandidate {
+	candidatesToReturn := make([]FeedCandidate, 0)
+
+	for _, candidate := range candidates {
+		var title *string
+		if candidate.Title != "" {
+			title = &candidate.Title
+		}
+
+		candidatesToReturn = append(candidatesToReturn, FeedCandidate{
+			URL:    candidate.URL,
+			Title:  title,
+			Format: candidate.Format,
+		})
+	}
+
+	return candidatesToReturn
+}
+
+type FeedPreview struct {
+	Url           string        `json:"url"`
+	Format        string        `json:"format"`
+	Title         string        `json:"title"`
+	Description   string        `json:"description"`
+	SiteURL       string        `json:"site_url"`
+	IconURL       string        `json:"icon_url"`
+	Language      string        `json:"language"`
+	LastBuildDate *time.Time    `json:"last_build_date"`
+	ItemCount     int           `json:"item_count"`
+	Items         []PreviewItem `json:"items"`
+	Warnings      []string      `json:"warnings"`
+}
+
+type PreviewItem struct {
+	Title       string      `json:"title"`
+	URL         *string     `json:"url"`
+	Guid        string      `json:"guid"`
+	PublishedAt time.Time   `json:"published_at"`
+	Excerpt     *string     `json:"excerpt"`
+	Enclosures  []Enclosure `json:"enclosures"`
+	Authors     []string    `json:"authors"`
+	Categories  []string    `json:"categories"`
+}
+
+func normalizedItemToPreviewItem(item normalizedItem) PreviewItem {
+	var url *string
+	if item.Link != "" {
+		url = &item.Link
+	}
+
+	var excerpt *string
+	if item.Excerpt != "" {
+		excerpt = &item.Excerpt
+	}
+
+	enclosures := make([]Enclosure, 0, len(item.Enclosures))
+	for _, enclosure := range item.Enclosures {
+		enclosureURL, ok := resolveURL(enclosure.URL, item.Base)
+		if !ok {
+			continue
+		}
+
+		var mimeType *string
+		if enclosure.Type != "" {
+			mimeType = &enclosure.Type
+		}
+
+		var length *int64
+		if enclosure.Length > 0 {
+			length = &enclosure.Length
+		}
+
+		enclosures = append(enclosures, Enclosure{
+			URL:      enclosureURL,
+			MimeType: mimeType,
+			Length:   length,
+		})
+	}
+
+	return PreviewItem{
+		Title:       item.Title,
+		URL:         url,
+		Guid:        item.Identity,
+		PublishedAt: item.PublishedAt,
+		Excerpt:     excerpt,
+		Enclosures:  enclosures,
+		Authors:     item.Authors,
+		Categories:  item.Categories,
+	}
+}
+
 type FeedFollow struct {
 	ID        uuid.UUID `json:"id"`
 	FeedID    uuid.UUID `json:"feed_id"`
@@ -91,14 +272,20 @@ func databaseFeedFollowsToFeedFollows(feedFollows []database.FeedFollow) []FeedF
 }
 
 type Post struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description"`
-	URL         string    `json:"url"`
-	PublishedAt time.Time `json:"published_at"`
-	FeedId      uuid.UUID `json:"feed_id"`
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Title       string      `json:"title"`
+	Description *string     `json:"description"`
+	URL         *string     `json:"url"`
+	PublishedAt time.Time   `json:"published_at"`
+	FeedId      uuid.UUID   `json:"feed_id"`
+	Guid        string      `json:"guid"`
+	Content     *string     `json:"content,omitempty"`
+	Excerpt     *string     `json:"excerpt"`
+	Enclosures  []Enclosure `json:"enclosures"`
+	Authors     []string    `json:"authors"`
+	Categories  []string    `json:"categories"`
 }
 
 func databasePostToPost(post database.Post) Post {
@@ -107,6 +294,21 @@ func databasePostToPost(post database.Post) Post {
 		description = &post.Description.String
 	}
 
+	var url *string
+	if post.Url.Valid {
+		url = &post.Url.String
+	}
+
+	var content *string
+	if post.Content.Valid {
+		content = &post.Content.String
+	}
+
+	var excerpt *string
+	if post.Excerpt.Valid {
+		excerpt = &post.Excerpt.String
+	}
+
 	return Post{
 		ID:          post.ID,
 		CreatedAt:   post.CreatedAt,
@@ -114,8 +316,14 @@ func databasePostToPost(post database.Post) Post {
 		PublishedAt: post.PublishedAt,
 		Title:       post.Title,
 		Description: description,
-		URL:         post.Url,
+		URL:         url,
 		FeedId:      post.FeedID,
+		Guid:        post.Guid,
+		Content:     content,
+		Excerpt:     excerpt,
+		Enclosures:  make([]Enclosure, 0),
+		Authors:     post.Authors,
+		Categories:  post.Categories,
 	}
 }
 
@@ -128,3 +336,55 @@ func databasePostsToPosts(posts []database.Post) []Post {
 
 	return postsToReturn
 }
+
+type Enclosure struct {
+	URL             string  `json:"url"`
+	MimeType        *string `json:"mime_type"`
+	Length          *int64  `json:"length"`
+	DurationSeconds *int32  `json:"duration_seconds"`
+	Episode         *int32  `json:"episode"`
+	Season          *int32  `json:"season"`
+	ImageURL        *string `json:"image_url"`
+}
+
+func databaseEnclosureToEnclosure(enclosure database.Enclosure) Enclosure {
+	var mimeType *string
+	if enclosure.MimeType.Valid {
+		mimeType = &enclosure.MimeType.String
+	}
+
+	var length *int64
+	if enclosure.Length.Valid {
+		length = &enclosure.Length.Int64
+	}
+
+	var durationSeconds *int32
+	if enclosure.DurationSeconds.Valid {
+		durationSeconds = &enclosure.DurationSeconds.Int32
+	}
+
+	var episode *int32
+	if enclosure.Episode.Valid {
+		episode = &enclosure.Episode.Int32
+	}
+
+	var season *int32
+	if enclosure.Season.Valid {
+		season = &enclosure.Season.Int32
+	}
+
+	var imageURL *string
+	if enclosure.ImageUrl.Valid {
+		imageURL = &enclosure.ImageUrl.String
+	}
+
+	return Enclosure{
+		URL:             enclosure.Url,
+		MimeType:        mimeType,
+		Length:          length,
+		DurationSeconds: durationSeconds,
+		Episode:         episode,
+		Season:          season,
+		ImageURL:        imageURL,
+	}
+}
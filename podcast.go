@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// feedEnclosure is a media file attached to an item, e.g. a podcast episode.
+type feedEnclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// podcastInfo holds the iTunes metadata of an episode. Zero values mean the
+// feed did not provide the value.
+type podcastInfo struct {
+	DurationSeconds int
+	Episode         int
+	Season          int
+	Image           string
+}
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// RSSMedia are the enclosure related elements of an RSS item, including the
+// iTunes and Media RSS extensions.
+type RSSMedia struct {
+	Enclosure      []RSSEnclosure `xml:"enclosure"`
+	ItunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ItunesEpisode  string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ItunesSeason   string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ItunesImage    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	MediaContent []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup   []struct {
+		Content   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnail []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaThumbnail []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+func (media RSSMedia) enclosures() []feedEnclosure {
+	enclosures := make([]feedEnclosure, 0, len(media.Enclosure))
+	seen := map[string]bool{}
+
+	add := func(url string, mimeType string, length string) {
+		url = strings.TrimSpace(url)
+		if url == "" || seen[url] {
+			return
+		}
+		seen[url] = true
+
+		size, _ := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+		enclosures = append(enclosures, feedEnclosure{
+			URL:    url,
+			Type:   strings.TrimSpace(mimeType),
+			Length: size,
+		})
+	}
+
+	for _, enclosure := range media.Enclosure {
+		add(enclosure.URL, enclosure.Type, enclosure.Length)
+	}
+
+	contents := media.MediaContent
+	for _, group := range media.MediaGroup {
+		contents = append(contents, group.Content...)
+	}
+	for _, content := range contents {
+		add(content.URL, content.Type, content.FileSize)
+	}
+
+	return enclosures
+}
+
+func (media RSSMedia) podcastInfo() podcastInfo {
+	info := podcastInfo{
+		DurationSeconds: parseMediaDuration(media.ItunesDuration),
+		Episode:         atoiOrZero(media.ItunesEpisode),
+		Season:          atoiOrZero(media.ItunesSeason),
+		Image:           strings.TrimSpace(media.ItunesImage.Href),
+	}
+
+	if info.DurationSeconds == 0 {
+		contents := media.MediaContent
+		for _, group := range media.MediaGroup {
+			contents = append(contents, group.Content...)
+		}
+		for _, content := range contents {
+			if duration := parseMediaDuration(content.Duration); duration > 0 {
+				info.DurationSeconds = duration
+				break
+			}
+		}
+	}
+
+	if info.Image == "" {
+		thumbnails := media.MediaThumbnail
+		for _, group := range media.MediaGroup {
+			thumbnails = append(thumbnails, group.Thumbnail...)
+		}
+		for _, thumbnail := range thumbnails {
+			if url := strings.TrimSpace(thumbnail.URL); url != "" {
+				info.Image = url
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+// parseMediaDuration parses an itunes:duration, which is either a number of
+// seconds or a duration in the form HH:MM:SS or MM:SS.
+func parseMediaDuration(value string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + int(n)
+	}
+
+	return seconds
+}
+
+func atoiOrZero(value string) int {
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+
+	return i
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const podcastFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+  xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+  xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Example Podcast</title>
+    <itunes:title>Example</itunes:title>
+    <link>https://podcast.example.com/</link>
+    <description>A show about Go</description>
+    <itunes:image href="https://podcast.example.com/cover.jpg"/>
+    <item>
+      <title>Episode 12: Real title</title>
+      <itunes:title>Short</itunes:title>
+      <media:title>Media title</media:title>
+      <link>https://podcast.example.com/12</link>
+      <guid>episode-12</guid>
+      <description>Show notes</description>
+      <media:description>Media description</media:description>
+      <author>host@example.com (The Host)</author>
+      <itunes:author>The Host</itunes:author>
+      <category>Technology</category>
+      <media:category>tech/software</media:category>
+      <enclosure url="https://cdn.example.com/12.mp3" type="audio/mpeg" length="12345678"/>
+      <media:content url="https://cdn.example.com/12.mp3" type="audio/mpeg" fileSize="12345678"/>
+      <itunes:duration>1:02:03</itunes:duration>
+      <itunes:episode>12</itunes:episode>
+      <itunes:season>2</itunes:season>
+      <itunes:image href="https://podcast.example.com/12.jpg"/>
+    </item>
+    <item>
+      <title>Episode 11</title>
+      <guid>episode-11</guid>
+      <media:group>
+        <media:content url="https://cdn.example.com/11.m4a" type="audio/mp4" fileSize="2048" duration="95"/>
+        <media:content url="https://cdn.example.com/11.ogg" type="audio/ogg"/>
+        <media:thumbnail url="https://podcast.example.com/11.jpg"/>
+      </media:group>
+    </item>
+  </channel>
+</rss>`
+
+func TestParseFeedPodcast(t *testing.T) {
+	doc, err := parseFeed([]byte(podcastFixture), "application/rss+xml")
+	if err != nil {
+		t.Fatalf("parseFeed returned error: %v", err)
+	}
+
+	if doc.Title != "Example Podcast" {
+		t.Errorf("Title = %q, want %q", doc.Title, "Example Podcast")
+	}
+	if doc.Icon != "https://podcast.example.com/cover.jpg" {
+		t.Errorf("Icon = %q, want the itunes:image", doc.Icon)
+	}
+	if len(doc.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(doc.Items))
+	}
+
+	tests := []struct {
+		name string
+		got  feedItem
+		want feedItem
+	}{
+		{
+			name: "item with enclosure and itunes elements",
+			got:  doc.Items[0],
+			want: feedItem{
+				Title:       "Episode 12: Real title",
+				Link:        "https://podcast.example.com/12",
+				Guid:        "episode-12",
+				Description: "Show notes",
+				Enclosures: []feedEnclosure{
+					{URL: "https://cdn.example.com/12.mp3", Type: "audio/mpeg", Length: 12345678},
+				},
+				Podcast: podcastInfo{
+					DurationSeconds: 3723,
+					Episode:         12,
+					Season:          2,
+					Image:           "https://podcast.example.com/12.jpg",
+				},
+				Authors:    []string{"host@example.com (The Host)"},
+				Categories: []string{"Technology"},
+			},
+		},
+		{
+			name: "item with media group",
+			got:  doc.Items[1],
+			want: feedItem{
+				Title: "Episode 11",
+				Guid:  "episode-11",
+				Enclosures: []feedEnclosure{
+					{URL: "https://cdn.example.com/11.m4a", Type: "audio/mp4", Length: 2048},
+					{URL: "https://cdn.example.com/11.ogg", Type: "audio/ogg"},
+				},
+				Podcast: podcastInfo{
+					DurationSeconds: 95,
+					Image:           "https://podcast.example.com/11.jpg",
+				},
+				Authors:    []string{},
+				Categories: []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("item = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMediaDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{value: "", want: 0},
+		{value: "95", want: 95},
+		{value: " 95 ", want: 95},
+		{value: "95.7", want: 95},
+		{value: "12:34", want: 754},
+		{value: "1:02:03", want: 3723},
+		{value: "01:02:03", want: 3723},
+		{value: "1:xx", want: 0},
+		{value: "-5", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseMediaDuration(tt.value); got != tt.want {
+				t.Errorf("parseMediaDuration(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// RDF is an RSS 1.0 document. Unlike RSS 2.0 the items are siblings of the
+// channel element and dates are taken from the Dublin Core module.
+type RDF struct {
+	XMLName xml.Name `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+	Channel struct {
+		Title           string `xml:"title"`
+		Link            string `xml:"link"`
+		Description     string `xml:"description"`
+		Language        string `xml:"http://purl.org/dc/elements/1.1/ language"`
+		Date            string `xml:"http://purl.org/dc/elements/1.1/ date"`
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Image struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	Item []struct {
+		About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+		Title       string   `xml:"title"`
+		Link        string   `xml:"link"`
+		Description string   `xml:"description"`
+		Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+		Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		Creator     []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Subject     []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	} `xml:"item"`
+}
+
+func (rdf RDF) toFeedDocument() feedDocument {
+	doc := feedDocument{
+		Format:      "rdf",
+		Title:       rdf.Channel.Title,
+		Description: rdf.Channel.Description,
+		Link:        strings.TrimSpace(rdf.Channel.Link),
+		Language:    rdf.Channel.Language,
+		Icon:        strings.TrimSpace(rdf.Image.URL),
+		Updated:     rdf.Channel.Date,
+		Hints:       parseUpdateHints("", rdf.Channel.UpdatePeriod, rdf.Channel.UpdateFrequency, nil, nil),
+		Items:       make([]feedItem, 0, len(rdf.Item)),
+	}
+
+	for _, item := range rdf.Item {
+		doc.Items = append(doc.Items, feedItem{
+			Title:       item.Title,
+			Link:        strings.TrimSpace(item.Link),
+			PubDate:     item.Date,
+			Guid:        item.About,
+			Description: item.Description,
+			Content:     item.Content,
+			Authors:     uniqueStrings(item.Creator),
+			Categories:  uniqueStrings(item.Subject),
+		})
+	}
+
+	return doc
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const rdfFixture = `<?xml version="1.0" encoding="utf-8"?>
+<rdf:RDF
+  xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns:dc="http://purl.org/dc/elements/1.1/"
+  xmlns:content="http://purl.org/rss/1.0/modules/content/"
+  xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://agency.example.gov/news">
+    <title>Agency News</title>
+    <link>https://agency.example.gov/news</link>
+    <description>Press releases</description>
+    <dc:language>en</dc:language>
+    <dc:date>2024-05-02T10:00:00Z</dc:date>
+  </channel>
+  <image rdf:about="https://agency.example.gov/logo.png">
+    <url>https://agency.example.gov/logo.png</url>
+  </image>
+  <item rdf:about="https://agency.example.gov/news/2">
+    <title>Second release</title>
+    <link> https://agency.example.gov/news/2 </link>
+    <description>Summary of the second release</description>
+    <content:encoded><![CDATA[<p>Full text</p>]]></content:encoded>
+    <dc:date>2024-05-02T09:00:00+02:00</dc:date>
+    <dc:creator>Press Office</dc:creator>
+    <dc:subject>Budget</dc:subject>
+    <dc:subject>budget</dc:subject>
+  </item>
+  <item rdf:about="https://agency.example.gov/news/1">
+    <title>First release</title>
+    <link>https://agency.example.gov/news/1</link>
+  </item>
+</rdf:RDF>`
+
+func TestParseFeedRDF(t *testing.T) {
+	doc, err := parseFeed([]byte(rdfFixture), "application/rdf+xml")
+	if err != nil {
+		t.Fatalf("parseFeed returned error: %v", err)
+	}
+
+	if doc.Format != "rdf" {
+		t.Errorf("Format = %q, want %q", doc.Format, "rdf")
+	}
+	if doc.Title != "Agency News" || doc.Description != "Press releases" || doc.Language != "en" {
+		t.Errorf("metadata = %q, %q, %q", doc.Title, doc.Description, doc.Language)
+	}
+	if doc.Icon != "https://agency.example.gov/logo.png" || doc.Updated != "2024-05-02T10:00:00Z" {
+		t.Errorf("Icon = %q, Updated = %q", doc.Icon, doc.Updated)
+	}
+	if len(doc.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(doc.Items))
+	}
+
+	tests := []struct {
+		name string
+		got  feedItem
+		want feedItem
+	}{
+		{
+			name: "item with dublin core elements",
+			got:  doc.Items[0],
+			want: feedItem{
+				Title:       "Second release",
+				Link:        "https://agency.example.gov/news/2",
+				PubDate:     "2024-05-02T09:00:00+02:00",
+				Guid:        "https://agency.example.gov/news/2",
+				Description: "Summary of the second release",
+				Content:     "<p>Full text</p>",
+				Authors:     []string{"Press Office"},
+				Categories:  []string{"Budget"},
+			},
+		},
+		{
+			name: "item without a date",
+			got:  doc.Items[1],
+			want: feedItem{
+				Title:      "First release",
+				Link:       "https://agency.example.gov/news/1",
+				Guid:       "https://agency.example.gov/news/1",
+				Authors:    []string{},
+				Categories: []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("item = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
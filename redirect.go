@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/timokae/boot.dev-aggregator/internal/database"
+)
+
+// moveFeedURL points a feed to the URL it was permanently redirected to. If
+// another feed already uses that URL, or an equivalent one, the two feeds are
+// merged: follows and posts are moved to the existing feed and the redirected
+// feed is deleted. The returned feed is the one that now owns the URL.
+func moveFeedURL(ctx context.Context, conn *sql.DB, db *database.Queries, feed database.Feed, newURL string) (database.Feed, error) {
+	newURL, err := canonicalFeedURL(newURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	if newURL == feed.Url {
+		return feed, nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := db.WithTx(tx)
+
+	target, err := qtx.GetOtherFeedByUrls(ctx, database.GetOtherFeedByUrlsParams{
+		Urls: equivalentFeedURLs(newURL),
+		ID:   feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = qtx.UpdateFeedUrl(ctx, database.UpdateFeedUrlParams{
+			ID:  feed.ID,
+			Url: newURL,
+		})
+		if err != nil {
+			return database.Feed{}, err
+		}
+
+		feed.Url = newURL
+		return feed, tx.Commit()
+	}
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	log.Printf("Merging feed %s into %s", feed.ID, target.ID)
+
+	err = qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+		ToFeedID:   target.ID,
+		FromFeedID: feed.ID,
+	})
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	err = qtx.MovePosts(ctx, database.MovePostsParams{
+		ToFeedID:   target.ID,
+		FromFeedID: feed.ID,
+	})
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	err = qtx.DeleteFeed(ctx, feed.ID)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	return target, tx.Commit()
+}
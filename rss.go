@@ -1,18 +1,15 @@
 package main
 
 import (
-	"context"
-	"database/sql"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
+	"mime"
 	"strings"
-	"sync"
-	"time"
-
-	"github.com/google/uuid"
-	"github.com/timokae/boot.dev-aggregator/internal/database"
 )
 
 type RSS struct {
@@ -21,125 +18,252 @@ type RSS struct {
 	Version string   `xml:"version,attr"`
 	Atom    string   `xml:"atom,attr"`
 	Channel struct {
-		Text  string `xml:",chardata"`
-		Title string `xml:"title"`
-		Link  struct {
-			Text string `xml:",chardata"`
+		Text string `xml:",chardata"`
+		// itunes:title must be declared before title, which would match it
+		// as well.
+		ItunesTitle     string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+		Title           string    `xml:"title"`
+		Link            []RSSLink `xml:"link"`
+		Description     string    `xml:"description"`
+		Generator       string    `xml:"generator"`
+		Language        string    `xml:"language"`
+		LastBuildDate   string    `xml:"lastBuildDate"`
+		PubDate         string    `xml:"pubDate"`
+		TTL             string    `xml:"ttl"`
+		SkipHours       []string  `xml:"skipHours>hour"`
+		SkipDays        []string  `xml:"skipDays>day"`
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		// itunes:image must be declared before image, which would match it
+		// as well.
+		ItunesImage struct {
 			Href string `xml:"href,attr"`
-			Rel  string `xml:"rel,attr"`
-			Type string `xml:"type,attr"`
-		} `xml:"link"`
-		Description   string `xml:"description"`
-		Generator     string `xml:"generator"`
-		Language      string `xml:"language"`
-		LastBuildDate string `xml:"lastBuildDate"`
-		Item          []struct {
-			Text        string `xml:",chardata"`
-			Title       string `xml:"title"`
-			Link        string `xml:"link"`
-			PubDate     string `xml:"pubDate"`
-			Guid        string `xml:"guid"`
-			Description string `xml:"description"`
+		} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Image struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+		Item []struct {
+			Text string `xml:",chardata"`
+			// The iTunes and Media RSS elements sharing their name with an
+			// RSS element must be declared before it, which would match
+			// them as well.
+			ItunesTitle      string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+			ItunesAuthor     string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+			MediaTitle       string    `xml:"http://search.yahoo.com/mrss/ title"`
+			MediaDescription string    `xml:"http://search.yahoo.com/mrss/ description"`
+			MediaCategory    []string  `xml:"http://search.yahoo.com/mrss/ category"`
+			Title            string    `xml:"title"`
+			Link             []RSSLink `xml:"link"`
+			PubDate          string    `xml:"pubDate"`
+			Guid             string    `xml:"guid"`
+			Description      string    `xml:"description"`
+			Content          string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			Author           string    `xml:"author"`
+			Creator          []string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Category         []string  `xml:"category"`
+			RSSMedia
 		} `xml:"item"`
 	} `xml:"channel"`
 }
 
-func fetchRSS(url string) (RSS, error) {
-	log.Printf("Fetching %s", url)
+// feedDocument is the format independent representation of a fetched feed.
+// Every supported format is converted into it before posts are created.
+type feedDocument struct {
+	Format      string
+	Title       string
+	Description string
+	Link        string
+	Language    string
+	Icon        string
+	Updated     string
+	Hints       updateHints
+	Items       []feedItem
+}
 
-	res, err := http.Get(url)
-	if err != nil {
-		return RSS{}, err
+type feedItem struct {
+	Title       string
+	Link        string
+	PubDate     string
+	Guid        string
+	Description string
+	Content     string
+	Enclosures  []feedEnclosure
+	Podcast     podcastInfo
+	Authors     []string
+	Categories  []string
+}
+
+// identity returns a stable identifier of the item within its feed. Items
+// without a guid are identified by their link and, as a last resort, by a
+// hash of their content.
+func (item feedItem) identity() string {
+	if guid := strings.TrimSpace(item.Guid); guid != "" {
+		return guid
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return RSS{}, fmt.Errorf("got status code %d", res.StatusCode)
+	if item.Link != "" {
+		return item.Link
 	}
 
-	rss := RSS{}
-	decoder := xml.NewDecoder(res.Body)
-	err = decoder.Decode(&rss)
-	if err != nil {
-		return RSS{}, err
+	hash := sha256.Sum256([]byte(item.Title + "\n" + item.Description))
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// RSSLink is a link element of a channel or an item. The element shares its
+// name with atom:link, which is decoded into the same slice.
+type RSSLink struct {
+	Text string `xml:",chardata"`
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// textLink returns the first link with text. atom:link elements only have
+// attributes and are skipped.
+func textLink(links []RSSLink) string {
+	for _, link := range links {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			return text
+		}
 	}
 
-	return rss, nil
+	return ""
 }
 
-func scrapeFeed(db *database.Queries, waitGroup *sync.WaitGroup, feed database.Feed) {
-	defer waitGroup.Done()
+func (rss RSS) toFeedDocument() feedDocument {
 
-	log.Printf("Fetching post of %s", feed.Url)
+	icon := rss.Channel.Image.URL
+	if icon == "" {
+		icon = rss.Channel.ItunesImage.Href
+	}
 
-	_, err := db.MarkFeedFetched(context.Background(), feed.ID)
-	if err != nil {
-		log.Println("Error marking feed as fetched:", err)
-		return
+	updated := rss.Channel.LastBuildDate
+	if updated == "" {
+		updated = rss.Channel.PubDate
 	}
 
-	rss, err := fetchRSS(feed.Url)
-	if err != nil {
-		log.Println("error fetching rss feed", err)
-		return
+	doc := feedDocument{
+		Format:      "rss",
+		Title:       rss.Channel.Title,
+		Description: rss.Channel.Description,
+		Link:        textLink(rss.Channel.Link),
+		Language:    rss.Channel.Language,
+		Icon:        strings.TrimSpace(icon),
+		Updated:     updated,
+		Hints: parseUpdateHints(
+			rss.Channel.TTL,
+			rss.Channel.UpdatePeriod,
+			rss.Channel.UpdateFrequency,
+			rss.Channel.SkipHours,
+			rss.Channel.SkipDays,
+		),
+		Items: make([]feedItem, 0, len(rss.Channel.Item)),
 	}
 
-	for _, post := range rss.Channel.Item {
-		description := sql.NullString{}
-		if post.Description != "" {
-			description.String = post.Description
-			description.Valid = true
-		}
+	for _, item := range rss.Channel.Item {
+		doc.Items = append(doc.Items, feedItem{
+			Title:       item.Title,
+			Link:        textLink(item.Link),
+			PubDate:     item.PubDate,
+			Guid:        item.Guid,
+			Description: item.Description,
+			Content:     item.Content,
+			Enclosures:  item.enclosures(),
+			Podcast:     item.podcastInfo(),
+			Authors:     uniqueStrings(append([]string{item.Author}, item.Creator...)),
+			Categories:  uniqueStrings(item.Category),
+		})
+	}
 
-		t, err := time.Parse(time.RFC1123Z, post.PubDate)
-		if err != nil {
-			log.Printf("could not parse date %v with err %v", post.PubDate, err)
+	return doc
+}
+
+// uniqueStrings trims the values and removes empty values as well as
+// duplicates, ignoring case.
+func uniqueStrings(values []string) []string {
+	unique := make([]string, 0, len(values))
+	seen := map[string]bool{}
+
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		key := strings.ToLower(value)
+		if value == "" || seen[key] {
 			continue
 		}
 
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   time.Now().UTC(),
-			UpdatedAt:   time.Now().UTC(),
-			Title:       post.Title,
-			Description: description,
-			PublishedAt: t,
-			Url:         post.Link,
-			FeedID:      feed.ID,
-		})
-		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
-				continue
-			}
-			log.Println("failed to create post", err)
-		}
+		seen[key] = true
+		unique = append(unique, value)
 	}
 
-	log.Printf("Feed %s collected, found %d posts", feed.Name, len(rss.Channel.Item))
+	return unique
 }
 
-func scrapeFeeds(
-	db *database.Queries,
-	concurrency int,
-	timeBetweenRequest time.Duration,
-) {
-	log.Printf("Scraping on %v goroutines every %s duration", concurrency, timeBetweenRequest)
+var errUnknownFeedFormat = errors.New("unknown feed format")
 
-	ticker := time.NewTicker(timeBetweenRequest)
-	for ; ; <-ticker.C {
-		feeds, err := db.GetNextFeedsToFetch(context.Background(), int32(concurrency))
+// rootElement returns the local name of the first element in an XML document.
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
 		if err != nil {
-			log.Println("error fetching feeds", err)
-			continue
+			return "", err
 		}
 
-		var waitGroup sync.WaitGroup
-		for _, feed := range feeds {
-			waitGroup.Add(1)
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// isJSONFeed reports whether a response body is a JSON Feed, either by its
+// Content-Type or, as many servers send text/plain, by sniffing the body.
+func isJSONFeed(data []byte, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/feed+json" || mediaType == "application/json") {
+		return true
+	}
+
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
 
-			go scrapeFeed(db, &waitGroup, feed)
+func parseFeed(data []byte, contentType string) (feedDocument, error) {
+	if isJSONFeed(data, contentType) {
+		feed := JSONFeed{}
+		err := json.Unmarshal(data, &feed)
+		if err != nil {
+			return feedDocument{}, err
 		}
+		return feed.toFeedDocument(), nil
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return feedDocument{}, err
+	}
 
-		waitGroup.Wait()
+	switch root {
+	case "rss":
+		rss := RSS{}
+		err = xml.Unmarshal(data, &rss)
+		if err != nil {
+			return feedDocument{}, err
+		}
+		return rss.toFeedDocument(), nil
+	case "feed":
+		atom := Atom{}
+		err = xml.Unmarshal(data, &atom)
+		if err != nil {
+			return feedDocument{}, err
+		}
+		return atom.toFeedDocument(), nil
+	case "RDF":
+		rdf := RDF{}
+		err = xml.Unmarshal(data, &rdf)
+		if err != nil {
+			return feedDocument{}, err
+		}
+		return rdf.toFeedDocument(), nil
+	default:
+		return feedDocument{}, fmt.Errorf("%w: root element <%s>", errUnknownFeedFormat, root)
 	}
 }
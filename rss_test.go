@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseFeedFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		want        string
+		wantErr     bool
+		wantUnknown bool
+	}{
+		{
+			name:        "rss",
+			data:        `<?xml version="1.0"?><rss version="2.0"><channel><title>RSS</title></channel></rss>`,
+			contentType: "application/rss+xml",
+			want:        "rss",
+		},
+		{
+			name:        "atom",
+			data:        `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title></feed>`,
+			contentType: "application/atom+xml",
+			want:        "atom",
+		},
+		{
+			name:        "atom served as text/xml after a comment",
+			data:        "<?xml version=\"1.0\"?>\n<!-- generated -->\n<feed xmlns=\"http://www.w3.org/2005/Atom\"><title>Atom</title></feed>",
+			contentType: "text/xml; charset=utf-8",
+			want:        "atom",
+		},
+		{
+			name:        "rdf",
+			data:        `<?xml version="1.0"?><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel><title>RDF</title></channel></rdf:RDF>`,
+			contentType: "application/rdf+xml",
+			want:        "rdf",
+		},
+		{
+			name:        "json feed",
+			data:        `{"version": "https://jsonfeed.org/version/1.1", "title": "JSON", "items": []}`,
+			contentType: "application/feed+json",
+			want:        "json",
+		},
+		{
+			name:        "json feed served as text/plain",
+			data:        "\n  {\"version\": \"https://jsonfeed.org/version/1.1\", \"items\": []}",
+			contentType: "text/plain; charset=utf-8",
+			want:        "json",
+		},
+		{
+			name:        "invalid json",
+			data:        `{"items": [`,
+			contentType: "application/feed+json",
+			wantErr:     true,
+		},
+		{
+			name:        "unknown root element",
+			data:        `<?xml version="1.0"?><html><body>Not a feed</body></html>`,
+			contentType: "text/xml",
+			wantErr:     true,
+			wantUnknown: true,
+		},
+		{
+			name:        "not xml",
+			data:        `Not a feed`,
+			contentType: "text/plain",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseFeed([]byte(tt.data), tt.contentType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseFeed returned format %q, want an error", doc.Format)
+				}
+				if tt.wantUnknown && !errors.Is(err, errUnknownFeedFormat) {
+					t.Errorf("parseFeed error = %v, want %v", err, errUnknownFeedFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+
+			if doc.Format != tt.want {
+				t.Errorf("Format = %q, want %q", doc.Format, tt.want)
+			}
+		})
+	}
+}
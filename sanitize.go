@@ -0,0 +1,327 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const excerptLength = 280
+
+// allowedTags maps every tag kept by sanitizeHTML to the attributes it may
+// keep. All other tags are removed while their text is kept.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"li":         nil,
+	"ol":         nil,
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// droppedTags are removed together with everything inside of them.
+var droppedTags = map[string]bool{
+	"embed":    true,
+	"form":     true,
+	"iframe":   true,
+	"math":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"title":    true,
+}
+
+var voidTags = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// urlAttributes are resolved against the base URL and must use one of the
+// allowed schemes, otherwise the attribute is removed.
+var urlAttributes = map[string]bool{
+	"cite": true,
+	"href": true,
+	"src":  true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// itemBaseURL returns the URL relative links of an item are resolved against.
+func itemBaseURL(link string, feedURL string) *url.URL {
+	for _, candidate := range []string{link, feedURL} {
+		base, err := url.Parse(candidate)
+		if err == nil && base.IsAbs() {
+			return base
+		}
+	}
+
+	return nil
+}
+
+func resolveURL(value string, base *url.URL) (string, bool) {
+	ref, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+
+	if !allowedSchemes[strings.ToLower(ref.Scheme)] {
+		return "", false
+	}
+
+	return ref.String(), true
+}
+
+// isTrackingPixel reports whether an image is at most one pixel large.
+func isTrackingPixel(token html.Token) bool {
+	for _, attr := range token.Attr {
+		if attr.Key != "width" && attr.Key != "height" {
+			continue
+		}
+
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(attr.Val), "px"))
+		if err == nil && size <= 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sanitizeAttributes(token html.Token, base *url.URL) []html.Attribute {
+	attrs := make([]html.Attribute, 0, len(token.Attr))
+
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !containsString(allowedTags[token.Data], attr.Key) {
+			continue
+		}
+
+		if urlAttributes[attr.Key] {
+			resolved, ok := resolveURL(attr.Val, base)
+			if !ok {
+				continue
+			}
+			attr.Val = resolved
+		}
+
+		attrs = append(attrs, html.Attribute{Key: attr.Key, Val: attr.Val})
+	}
+
+	if token.Data == "a" {
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+	}
+
+	return attrs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sanitizeHTML keeps only the allow-listed tags and attributes of an HTML
+// fragment and resolves relative URLs against base.
+func sanitizeHTML(input string, base *url.URL) string {
+	if input == "" {
+		return ""
+	}
+
+	var out strings.Builder
+	var open []string
+	dropDepth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.TextToken:
+			if dropDepth == 0 {
+				out.WriteString(html.EscapeString(token.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tokenType == html.StartTagToken && !voidTags[token.Data] {
+					dropDepth++
+				}
+				continue
+			}
+
+			if dropDepth > 0 {
+				continue
+			}
+
+			if _, ok := allowedTags[token.Data]; !ok {
+				continue
+			}
+
+			if token.Data == "img" && isTrackingPixel(token) {
+				continue
+			}
+
+			clean := html.Token{
+				Type: html.StartTagToken,
+				Data: token.Data,
+				Attr: sanitizeAttributes(token, base),
+			}
+			if token.Data == "img" && !hasAttribute(clean, "src") {
+				continue
+			}
+
+			out.WriteString(clean.String())
+			if !voidTags[token.Data] {
+				open = append(open, token.Data)
+			}
+		case html.EndTagToken:
+			if droppedTags[token.Data] {
+				if dropDepth > 0 {
+					dropDepth--
+				}
+				continue
+			}
+
+			if dropDepth > 0 {
+				continue
+			}
+
+			// Close the tag only if it is open, closing everything that was
+			// opened after it.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+
+	return strings.TrimSpace(out.String())
+}
+
+func hasAttribute(token html.Token, key string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// htmlExcerpt returns the text of an HTML fragment with collapsed whitespace,
+// truncated at a word boundary to at most maxLength characters.
+func htmlExcerpt(input string, maxLength int) string {
+	var text strings.Builder
+	dropDepth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.TextToken:
+			if dropDepth == 0 {
+				text.WriteString(token.Data)
+			}
+		case html.StartTagToken:
+			if droppedTags[token.Data] {
+				dropDepth++
+			}
+			text.WriteString(" ")
+		case html.EndTagToken:
+			if droppedTags[token.Data] && dropDepth > 0 {
+				dropDepth--
+			}
+			text.WriteString(" ")
+		case html.SelfClosingTagToken:
+			text.WriteString(" ")
+		}
+	}
+
+	excerpt := strings.Join(strings.Fields(text.String()), " ")
+	if utf8.RuneCountInString(excerpt) <= maxLength {
+		return excerpt
+	}
+
+	runes := []rune(excerpt)[:maxLength]
+	truncated := string(runes)
+	if i := strings.LastIndex(truncated, " "); i > 0 {
+		truncated = truncated[:i]
+	}
+
+	return truncated + "…"
+}
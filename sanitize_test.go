@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	base, err := url.Parse("https://example.com/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  "",
+		},
+		{
+			name:  "allowed tags are kept",
+			input: "<p>Hello <strong>world</strong></p>",
+			want:  "<p>Hello <strong>world</strong></p>",
+		},
+		{
+			name:  "script is removed with its content",
+			input: "<p>before</p><script>alert('x')</script><p>after</p>",
+			want:  "<p>before</p><p>after</p>",
+		},
+		{
+			name:  "nested script in unknown tag is removed",
+			input: "<div><script type=\"text/javascript\">document.cookie</script>text</div>",
+			want:  "text",
+		},
+		{
+			name:  "style and iframe are removed with their content",
+			input: "<style>p { color: red }</style><iframe src=\"https://evil.example\">frame</iframe>ok",
+			want:  "ok",
+		},
+		{
+			name:  "unknown tags keep their text",
+			input: "<section><span>kept</span></section>",
+			want:  "kept",
+		},
+		{
+			name:  "event handler attributes are removed",
+			input: "<p onclick=\"alert(1)\">click</p><img src=\"/a.png\" onerror=\"alert(2)\">",
+			want:  "<p>click</p><img src=\"https://example.com/a.png\">",
+		},
+		{
+			name:  "style attributes are removed",
+			input: "<p style=\"background:url(javascript:alert(1))\">text</p>",
+			want:  "<p>text</p>",
+		},
+		{
+			name:  "javascript links lose their href",
+			input: "<a href=\"javascript:alert(1)\">link</a>",
+			want:  "<a rel=\"nofollow noopener noreferrer\">link</a>",
+		},
+		{
+			name:  "javascript links with mixed case and whitespace lose their href",
+			input: "<a href=\" JavaScript:alert(1)\">link</a>",
+			want:  "<a rel=\"nofollow noopener noreferrer\">link</a>",
+		},
+		{
+			name:  "data images are removed",
+			input: "<img src=\"data:image/png;base64,AAAA\">",
+			want:  "",
+		},
+		{
+			name:  "relative links are resolved and marked nofollow",
+			input: "<a href=\"../about\" title=\"About\">about</a>",
+			want:  "<a href=\"https://example.com/about\" title=\"About\" rel=\"nofollow noopener noreferrer\">about</a>",
+		},
+		{
+			name:  "mailto links are kept",
+			input: "<a href=\"mailto:me@example.com\">mail</a>",
+			want:  "<a href=\"mailto:me@example.com\" rel=\"nofollow noopener noreferrer\">mail</a>",
+		},
+		{
+			name:  "tracking pixel is removed",
+			input: "<p>text</p><img src=\"https://tracker.example/p.gif\" width=\"1\" height=\"1\">",
+			want:  "<p>text</p>",
+		},
+		{
+			name:  "tracking pixel with px sizes is removed",
+			input: "<img src=\"https://tracker.example/p.gif\" width=\"1px\" height=\"1px\"/>",
+			want:  "",
+		},
+		{
+			name:  "zero sized image is removed",
+			input: "<img src=\"https://tracker.example/p.gif\" height=\"0\">",
+			want:  "",
+		},
+		{
+			name:  "regular images are kept",
+			input: "<img src=\"https://example.com/photo.jpg\" width=\"640\" height=\"480\" alt=\"Photo\">",
+			want:  "<img src=\"https://example.com/photo.jpg\" width=\"640\" height=\"480\" alt=\"Photo\">",
+		},
+		{
+			name:  "unclosed tags are closed",
+			input: "<ul><li><em>one",
+			want:  "<ul><li><em>one</em></li></ul>",
+		},
+		{
+			name:  "stray end tags are ignored",
+			input: "text</p></div>",
+			want:  "text",
+		},
+		{
+			name:  "text is escaped",
+			input: "a &lt;script&gt; b",
+			want:  "a &lt;script&gt; b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeHTML(tt.input, base)
+			if got != tt.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLExcerpt(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "tags are removed and whitespace collapsed",
+			input:     "<p>Hello</p>\n\n<p>world</p>",
+			maxLength: 100,
+			want:      "Hello world",
+		},
+		{
+			name:      "script content is dropped",
+			input:     "<script>var x = 1;</script><p>text</p>",
+			maxLength: 100,
+			want:      "text",
+		},
+		{
+			name:      "truncated at a word boundary",
+			input:     "<p>one two three</p>",
+			maxLength: 10,
+			want:      "one two…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := htmlExcerpt(tt.input, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("htmlExcerpt(%q, %d) = %q, want %q", tt.input, tt.maxLength, got, tt.want)
+			}
+		})
+	}
+}
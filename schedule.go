@@ -0,0 +1,160 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updateHints are the update schedule a feed declares for itself through the
+// RSS ttl, skipHours and skipDays elements and the syndication module. Zero
+// values mean the feed did not declare anything.
+type updateHints struct {
+	Interval  time.Duration
+	SkipHours []int
+	SkipDays  []time.Weekday
+}
+
+// syndicationPeriods are the sy:updatePeriod values.
+var syndicationPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseUpdateHints parses the raw schedule elements of a feed. The interval
+// is the longer one of the ttl, given in minutes, and the syndication period
+// divided by its frequency. Invalid values are ignored.
+func parseUpdateHints(ttl string, updatePeriod string, updateFrequency string, skipHours []string, skipDays []string) updateHints {
+	hints := updateHints{}
+
+	if minutes := atoiOrZero(ttl); minutes > 0 {
+		hints.Interval = time.Duration(minutes) * time.Minute
+	}
+
+	if period, ok := syndicationPeriods[strings.ToLower(strings.TrimSpace(updatePeriod))]; ok {
+		frequency := atoiOrZero(updateFrequency)
+		if frequency < 1 {
+			frequency = 1
+		}
+		hints.Interval = max(hints.Interval, period/time.Duration(frequency))
+	}
+
+	for _, value := range skipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		// Some feeds number the hours from 1 to 24.
+		hints.SkipHours = append(hints.SkipHours, hour%24)
+	}
+
+	for _, value := range skipDays {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(value))]; ok {
+			hints.SkipDays = append(hints.SkipDays, day)
+		}
+	}
+
+	return hints
+}
+
+// skips reports whether the feed asked not to be fetched at t. Skipped hours
+// are in GMT.
+func (hints updateHints) skips(t time.Time) bool {
+	t = t.UTC()
+
+	for _, hour := range hints.SkipHours {
+		if t.Hour() == hour {
+			return true
+		}
+	}
+
+	for _, day := range hints.SkipDays {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// adaptiveSampleSize is the number of latest posts the posting frequency of
+// a feed is estimated from.
+const adaptiveSampleSize = 20
+
+// minPostGap is the shortest gap between two posts that counts towards the
+// posting frequency. Posts closer together, like undated items first seen in
+// the same scrape, were published at once.
+const minPostGap = time.Second
+
+// adaptiveInterval estimates how often a feed should be polled from the
+// publication times of its latest posts, newest first. It reports false if
+// there are too few posts to tell.
+func adaptiveInterval(now time.Time, published []time.Time) (time.Duration, bool) {
+	gaps := make([]time.Duration, 0, len(published))
+	for i := 1; i < len(published); i++ {
+		if gap := published[i-1].Sub(published[i]); gap >= minPostGap {
+			gaps = append(gaps, gap)
+		}
+	}
+
+	if len(gaps) == 0 {
+		return 0, false
+	}
+
+	slices.Sort(gaps)
+	median := gaps[len(gaps)/2]
+
+	// Polling twice per typical gap picks up most posts soon after they
+	// are published.
+	interval := median / 2
+
+	// A feed that has been quiet for longer than usual is polled less often
+	// the longer it stays quiet.
+	if quiet := now.Sub(published[0]); quiet > median {
+		interval = max(interval, quiet/2)
+	}
+
+	return interval, true
+}
+
+// nextFetchAt returns when a feed fetched at now is due again. The adaptive
+// poll interval of the feed replaces the default one, as does the interval
+// the feed declares if it is longer, but both are kept within the global
+// bounds. A pollInterval of zero means the feed has none. Skipped hours and
+// days are moved past as long as that does not exceed the maximum interval.
+func (s *scraper) nextFetchAt(now time.Time, hints updateHints, pollInterval time.Duration) time.Time {
+	interval := s.interval
+	if pollInterval > 0 {
+		interval = pollInterval
+	}
+	if hints.Interval > 0 && (pollInterval == 0 || hints.Interval > interval) {
+		interval = hints.Interval
+	}
+	interval = min(max(interval, s.minInterval), s.maxInterval)
+
+	next := now.Add(interval)
+	latest := now.Add(s.maxInterval)
+	for next.Before(latest) && hints.skips(next) {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	if next.After(latest) {
+		return latest
+	}
+
+	return next
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseUpdateHints(t *testing.T) {
+	tests := []struct {
+		name            string
+		ttl             string
+		updatePeriod    string
+		updateFrequency string
+		skipHours       []string
+		skipDays        []string
+		want            updateHints
+	}{
+		{
+			name: "nothing declared",
+			want: updateHints{},
+		},
+		{
+			name: "ttl in minutes",
+			ttl:  " 60 ",
+			want: updateHints{Interval: time.Hour},
+		},
+		{
+			name: "invalid ttl",
+			ttl:  "soon",
+			want: updateHints{},
+		},
+		{
+			name: "negative ttl",
+			ttl:  "-5",
+			want: updateHints{},
+		},
+		{
+			name:            "syndication period divided by frequency",
+			updatePeriod:    "daily",
+			updateFrequency: "2",
+			want:            updateHints{Interval: 12 * time.Hour},
+		},
+		{
+			name:         "syndication period without frequency",
+			updatePeriod: " Hourly ",
+			want:         updateHints{Interval: time.Hour},
+		},
+		{
+			name:            "invalid syndication frequency",
+			updatePeriod:    "weekly",
+			updateFrequency: "0",
+			want:            updateHints{Interval: 7 * 24 * time.Hour},
+		},
+		{
+			name:         "unknown syndication period",
+			updatePeriod: "fortnightly",
+			want:         updateHints{},
+		},
+		{
+			name:         "longer of ttl and syndication period",
+			ttl:          "120",
+			updatePeriod: "hourly",
+			want:         updateHints{Interval: 2 * time.Hour},
+		},
+		{
+			name:      "skip hours",
+			skipHours: []string{"0", " 5 ", "23", "24", "25", "-1", "noon"},
+			want:      updateHints{SkipHours: []int{0, 5, 23, 0}},
+		},
+		{
+			name:     "skip days",
+			skipDays: []string{"Monday", " sunday ", "Funday"},
+			want:     updateHints{SkipDays: []time.Weekday{time.Monday, time.Sunday}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseUpdateHints(tt.ttl, tt.updatePeriod, tt.updateFrequency, tt.skipHours, tt.skipDays)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUpdateHints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateHintsSkips(t *testing.T) {
+	hints := updateHints{
+		SkipHours: []int{0, 13},
+		SkipDays:  []time.Weekday{time.Sunday},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "skipped hour", t: time.Date(2024, 5, 6, 13, 30, 0, 0, time.UTC), want: true},
+		{name: "midnight", t: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), want: true},
+		{name: "other hour", t: time.Date(2024, 5, 6, 14, 0, 0, 0, time.UTC), want: false},
+		{name: "skipped day", t: time.Date(2024, 5, 5, 10, 0, 0, 0, time.UTC), want: true},
+		{name: "skipped hour in GMT", t: time.Date(2024, 5, 6, 15, 30, 0, 0, time.FixedZone("CEST", 2*60*60)), want: true},
+		{name: "local hour is not skipped", t: time.Date(2024, 5, 6, 13, 30, 0, 0, time.FixedZone("CEST", 2*60*60)), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hints.skips(tt.t); got != tt.want {
+				t.Errorf("skips(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFetchAt(t *testing.T) {
+	// A Monday.
+	now := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+
+	s := &scraper{
+		interval:    time.Minute,
+		minInterval: time.Minute,
+		maxInterval: 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name    string
+		scraper *scraper
+		hints   updateHints
+		want    time.Time
+	}{
+		{
+			name:    "default interval",
+			scraper: s,
+			want:    now.Add(time.Minute),
+		},
+		{
+			name:    "declared interval",
+			scraper: s,
+			hints:   updateHints{Interval: time.Hour},
+			want:    now.Add(time.Hour),
+		},
+		{
+			name:    "declared interval above the maximum",
+			scraper: s,
+			hints:   updateHints{Interval: 7 * 24 * time.Hour},
+			want:    now.Add(24 * time.Hour),
+		},
+		{
+			name:    "default interval below the minimum",
+			scraper: &scraper{interval: 30 * time.Second, minInterval: 5 * time.Minute, maxInterval: time.Hour},
+			want:    now.Add(5 * time.Minute),
+		},
+		{
+			name:    "declared interval below the minimum",
+			scraper: &scraper{interval: time.Hour, minInterval: 15 * time.Minute, maxInterval: 24 * time.Hour},
+			hints:   updateHints{Interval: 5 * time.Minute},
+			want:    now.Add(15 * time.Minute),
+		},
+		{
+			name:    "skipped hours are moved past",
+			scraper: s,
+			hints:   updateHints{SkipHours: []int{10, 11}},
+			want:    time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "skipped day is moved past",
+			scraper: s,
+			hints:   updateHints{SkipDays: []time.Weekday{time.Monday}},
+			want:    time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "skipped hours and days",
+			scraper: s,
+			hints:   updateHints{SkipHours: []int{0, 1}, SkipDays: []time.Weekday{time.Monday}},
+			want:    time.Date(2024, 5, 7, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "skipping does not exceed the maximum interval",
+			scraper: &scraper{interval: time.Minute, minInterval: time.Minute, maxInterval: 6 * time.Hour},
+			hints:   updateHints{SkipDays: []time.Weekday{time.Monday}},
+			want:    now.Add(6 * time.Hour),
+		},
+		{
+			name:    "every day skipped",
+			scraper: s,
+			hints: updateHints{SkipDays: []time.Weekday{
+				time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+			}},
+			want: now.Add(24 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.scraper.nextFetchAt(now, tt.hints, 0)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextFetchAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFetchAtPollInterval(t *testing.T) {
+	now := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+
+	s := &scraper{
+		interval:    time.Minute,
+		minInterval: 5 * time.Minute,
+		maxInterval: 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name         string
+		hints        updateHints
+		pollInterval time.Duration
+		want         time.Time
+	}{
+		{
+			name:         "poll interval replaces the default interval",
+			pollInterval: 2 * time.Hour,
+			want:         now.Add(2 * time.Hour),
+		},
+		{
+			name:         "longer declared interval wins",
+			hints:        updateHints{Interval: 6 * time.Hour},
+			pollInterval: 2 * time.Hour,
+			want:         now.Add(6 * time.Hour),
+		},
+		{
+			name:         "shorter declared interval is ignored",
+			hints:        updateHints{Interval: time.Hour},
+			pollInterval: 2 * time.Hour,
+			want:         now.Add(2 * time.Hour),
+		},
+		{
+			name:         "poll interval below the minimum",
+			pollInterval: time.Millisecond,
+			want:         now.Add(5 * time.Minute),
+		},
+		{
+			name:         "poll interval above the maximum",
+			pollInterval: 30 * 24 * time.Hour,
+			want:         now.Add(24 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.nextFetchAt(now, tt.hints, tt.pollInterval)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextFetchAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	now := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+
+	// every returns count publication times, newest first, the newest one
+	// age before now.
+	every := func(gap time.Duration, count int, age time.Duration) []time.Time {
+		published := make([]time.Time, 0, count)
+		for i := 0; i < count; i++ {
+			published = append(published, now.Add(-age-time.Duration(i)*gap))
+		}
+		return published
+	}
+
+	tests := []struct {
+		name      string
+		published []time.Time
+		want      time.Duration
+		wantOK    bool
+	}{
+		{
+			name:      "no posts",
+			published: nil,
+			wantOK:    false,
+		},
+		{
+			name:      "single post",
+			published: every(time.Hour, 1, 0),
+			wantOK:    false,
+		},
+		{
+			name:      "posts first seen in the same scrape",
+			published: every(0, 10, time.Minute),
+			wantOK:    false,
+		},
+		{
+			name:      "posts microseconds apart",
+			published: every(time.Microsecond, 10, time.Minute),
+			wantOK:    false,
+		},
+		{
+			name:      "hourly posts",
+			published: every(time.Hour, 10, 10*time.Minute),
+			want:      30 * time.Minute,
+			wantOK:    true,
+		},
+		{
+			name:      "daily posts",
+			published: every(24*time.Hour, 10, time.Hour),
+			want:      12 * time.Hour,
+			wantOK:    true,
+		},
+		{
+			name:      "quiet feed",
+			published: every(24*time.Hour, 10, 10*24*time.Hour),
+			want:      5 * 24 * time.Hour,
+			wantOK:    true,
+		},
+		{
+			name: "median of irregular gaps",
+			published: []time.Time{
+				now.Add(-time.Hour),
+				now.Add(-2 * time.Hour),
+				now.Add(-4 * time.Hour),
+				now.Add(-14 * time.Hour),
+			},
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name: "posts of one scrape count as one",
+			published: []time.Time{
+				now.Add(-time.Hour),
+				now.Add(-time.Hour),
+				now.Add(-time.Hour),
+				now.Add(-5 * time.Hour),
+				now.Add(-9 * time.Hour),
+			},
+			want:   2 * time.Hour,
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := adaptiveInterval(now, tt.published)
+			if ok != tt.wantOK {
+				t.Fatalf("adaptiveInterval() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("adaptiveInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
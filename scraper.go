@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/timokae/boot.dev-aggregator/internal/database"
+)
+
+// scraper continuously fetches due feeds with a pool of workers and stores
+// their items as posts. Every feed is due again interval after it was
+// fetched, unless its posting frequency is known or it declares its own
+// update schedule, in which case the interval is bounded by minInterval and
+// maxInterval. The lease must be longer than the time a
+// claimed feed can spend queued and fetching. A maxFailures of zero never
+// disables feeds because of failures.
+type scraper struct {
+	conn         *sql.DB
+	db           *database.Queries
+	fetcher      *feedFetcher
+	maxFailures  int
+	workers      int
+	interval     time.Duration
+	minInterval  time.Duration
+	maxInterval  time.Duration
+	pollInterval time.Duration
+	lease        time.Duration
+}
+
+const (
+	feedBackoffBase = 5 * time.Minute
+	feedBackoffMax  = 24 * time.Hour
+)
+
+// feedBackoff returns how long to wait before fetching a feed again after it
+// failed the given number of times in a row.
+func feedBackoff(failures int32) time.Duration {
+	backoff := feedBackoffBase
+	for i := int32(1); i < failures; i++ {
+		backoff *= 2
+		if backoff >= feedBackoffMax {
+			return feedBackoffMax
+		}
+	}
+
+	return backoff
+}
+
+// isFeedGone reports whether a fetch error means the feed was removed for
+// good. A blocked address is an ordinary failure, as a single private DNS
+// answer may be temporary.
+func isFeedGone(err error) bool {
+	var statusErr statusCodeError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGone
+}
+
+// markFeedFetchFailed records a failed fetch and disables the feed once it
+// failed maxFailures times in a row, or right away if it is gone.
+func (s *scraper) markFeedFetchFailed(ctx context.Context, feed database.Feed, fetchErr error) {
+	failures := feed.ConsecutiveFailures + 1
+	nextFetchAt := time.Now().UTC().Add(max(feedBackoff(failures), s.interval))
+
+	err := s.db.MarkFeedFetchFailed(ctx, database.MarkFeedFetchFailedParams{
+		ID:          feed.ID,
+		LastError:   sql.NullString{String: fetchErr.Error(), Valid: true},
+		NextFetchAt: sql.NullTime{Time: nextFetchAt, Valid: true},
+	})
+	if err != nil {
+		log.Println("error marking feed fetch as failed", err)
+	}
+
+	if isFeedGone(fetchErr) || (s.maxFailures > 0 && int(failures) >= s.maxFailures) {
+		log.Printf("Disabling feed %s after %d failures: %v", feed.Url, failures, fetchErr)
+
+		err = s.db.DisableFeed(ctx, feed.ID)
+		if err != nil {
+			log.Println("error disabling feed", err)
+		}
+	}
+}
+
+func (s *scraper) scrapeFeed(ctx context.Context, feed database.Feed) {
+	log.Printf("Fetching post of %s", feed.Url)
+
+	result, err := s.fetcher.fetch(ctx, feed.Url, feed.Etag.String, feed.LastModified.String)
+	if err != nil {
+		log.Println("error fetching feed", err)
+		s.markFeedFetchFailed(ctx, feed, err)
+		return
+	}
+
+	// A feed that was not modified is scheduled by the hints it declared
+	// the last time it was.
+	hints := databaseFeedToUpdateHints(feed)
+	if !result.NotModified {
+		hints = result.Doc.Hints
+	}
+
+	pollInterval := time.Duration(feed.PollIntervalSeconds.Int32) * time.Second
+
+	_, err = s.db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+		ID:          feed.ID,
+		NextFetchAt: sql.NullTime{Time: s.nextFetchAt(time.Now().UTC(), hints, pollInterval), Valid: true},
+	})
+	if err != nil {
+		log.Println("Error marking feed as fetched:", err)
+	}
+
+	if result.PermanentURL != "" && result.PermanentURL != feed.Url {
+		log.Printf("Feed %s moved permanently to %s", feed.Url, result.PermanentURL)
+
+		feed, err = moveFeedURL(ctx, s.conn, s.db, feed, result.PermanentURL)
+		if err != nil {
+			log.Println("error updating feed url", err)
+			return
+		}
+	}
+
+	if result.NotModified {
+		log.Printf("Feed %s not modified", feed.Url)
+		s.updatePollInterval(ctx, feed, hints)
+		return
+	}
+
+	err = s.db.UpdateFeedCacheHeaders(ctx, database.UpdateFeedCacheHeadersParams{
+		ID:           feed.ID,
+		Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+		LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+	})
+	if err != nil {
+		log.Println("error updating feed cache headers", err)
+	}
+
+	doc := result.Doc
+	s.updateFeedMetadata(ctx, feed, doc)
+
+	// Items without a date share the time of the scrape they were first seen
+	// in.
+	firstSeen := time.Now().UTC()
+	unparsedDates, inserted, updated, unchanged := 0, 0, 0, 0
+	for _, item := range doc.Items {
+		post := normalizeItem(item, feed.Url, firstSeen)
+
+		unparsedPublishedAt := sql.NullString{}
+		if !post.DateParsed {
+			log.Printf("could not parse date %q of %s, using first seen time", post.PubDate, post.Link)
+			unparsedPublishedAt.String = post.PubDate
+			unparsedPublishedAt.Valid = true
+			unparsedDates++
+		}
+
+		created, err := s.db.UpsertPost(ctx, database.UpsertPostParams{
+			ID:                  uuid.New(),
+			CreatedAt:           time.Now().UTC(),
+			UpdatedAt:           time.Now().UTC(),
+			Title:               post.Title,
+			Description:         sql.NullString{String: post.Description, Valid: post.Description != ""},
+			PublishedAt:         post.PublishedAt,
+			Url:                 sql.NullString{String: post.Link, Valid: post.Link != ""},
+			FeedID:              feed.ID,
+			UnparsedPublishedAt: unparsedPublishedAt,
+			Guid:                post.Identity,
+			Content:             sql.NullString{String: post.Content, Valid: post.Content != ""},
+			Excerpt:             sql.NullString{String: post.Excerpt, Valid: post.Excerpt != ""},
+			Authors:             post.Authors,
+			Categories:          post.Categories,
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The post exists and its content did not change.
+			unchanged++
+		case err != nil:
+			log.Println("failed to upsert post", err)
+			continue
+		case created:
+			inserted++
+		default:
+			updated++
+		}
+
+		s.storeEnclosures(ctx, feed, post)
+	}
+
+	log.Printf(
+		"Feed %s collected, found %d posts (%d inserted, %d updated, %d unchanged, %d with unparseable dates)",
+		feed.Url, len(doc.Items), inserted, updated, unchanged, unparsedDates,
+	)
+
+	s.updatePollInterval(ctx, feed, hints)
+}
+
+// updatePollInterval recomputes the adaptive poll interval of a feed from its
+// latest posts and reschedules the feed with it.
+func (s *scraper) updatePollInterval(ctx context.Context, feed database.Feed, hints updateHints) {
+	published, err := s.db.GetRecentPostTimes(ctx, database.GetRecentPostTimesParams{
+		FeedID: feed.ID,
+		Limit:  adaptiveSampleSize,
+	})
+	if err != nil {
+		log.Println("error getting post times", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	interval, ok := adaptiveInterval(now, published)
+	if ok {
+		interval = min(max(interval, s.minInterval), s.maxInterval)
+	}
+
+	err = s.db.UpdateFeedPollInterval(ctx, database.UpdateFeedPollIntervalParams{
+		ID:                  feed.ID,
+		PollIntervalSeconds: sql.NullInt32{Int32: int32(interval / time.Second), Valid: ok},
+		NextFetchAt:         sql.NullTime{Time: s.nextFetchAt(now, hints, interval), Valid: true},
+	})
+	if err != nil {
+		log.Println("error updating feed poll interval", err)
+	}
+}
+
+// normalizedItem is a feed item prepared for storage.
+type normalizedItem struct {
+	feedItem
+	Identity    string
+	Base        *url.URL
+	Excerpt     string
+	PublishedAt time.Time
+	DateParsed  bool
+}
+
+// normalizeItem sanitizes the HTML of an item and determines its identity,
+// excerpt and publication date. Items without a parseable date are dated
+// firstSeen.
+func normalizeItem(item feedItem, feedURL string, firstSeen time.Time) normalizedItem {
+	// The identity is taken before sanitizing, so that it does not change
+	// with the sanitizer.
+	identity := item.identity()
+
+	base := itemBaseURL(item.Link, feedURL)
+	item.Description = sanitizeHTML(item.Description, base)
+	item.Content = sanitizeHTML(item.Content, base)
+
+	excerptSource := item.Description
+	if excerptSource == "" {
+		excerptSource = item.Content
+	}
+
+	publishedAt, ok := normalizePubDate(item.PubDate, firstSeen)
+
+	return normalizedItem{
+		feedItem:    item,
+		Identity:    identity,
+		Base:        base,
+		Excerpt:     htmlExcerpt(excerptSource, excerptLength),
+		PublishedAt: publishedAt,
+		DateParsed:  ok,
+	}
+}
+
+// feedMetadata is the channel level data of a feed prepared for storage.
+type feedMetadata struct {
+	Title         string
+	Description   string
+	SiteURL       string
+	IconURL       string
+	Language      string
+	LastBuildDate time.Time
+	HasBuildDate  bool
+}
+
+// normalizeFeedMetadata resolves the links of the document against the feed
+// URL, dropping them if they are invalid, and reduces the description to
+// plain text.
+func normalizeFeedMetadata(doc feedDocument, feedURL string) feedMetadata {
+	base := itemBaseURL("", feedURL)
+
+	siteURL := ""
+	if doc.Link != "" {
+		siteURL, _ = resolveURL(doc.Link, base)
+	}
+
+	iconURL := ""
+	if doc.Icon != "" {
+		iconURL, _ = resolveURL(doc.Icon, itemBaseURL(siteURL, feedURL))
+	}
+
+	lastBuildDate, ok := parsePubDate(doc.Updated)
+
+	return feedMetadata{
+		Title:         strings.TrimSpace(doc.Title),
+		Description:   htmlExcerpt(doc.Description, excerptLength),
+		SiteURL:       siteURL,
+		IconURL:       iconURL,
+		Language:      strings.TrimSpace(doc.Language),
+		LastBuildDate: lastBuildDate,
+		HasBuildDate:  ok,
+	}
+}
+
+// databaseFeedToUpdateHints returns the update hints stored with a feed.
+func databaseFeedToUpdateHints(feed database.Feed) updateHints {
+	hints := updateHints{}
+
+	if feed.UpdateIntervalSeconds.Valid {
+		hints.Interval = time.Duration(feed.UpdateIntervalSeconds.Int32) * time.Second
+	}
+
+	for _, hour := range feed.SkipHours {
+		hints.SkipHours = append(hints.SkipHours, int(hour))
+	}
+
+	for _, day := range feed.SkipDays {
+		hints.SkipDays = append(hints.SkipDays, time.Weekday(day))
+	}
+
+	return hints
+}
+
+// updateFeedMetadata stores the channel level data of the fetched document,
+// including its update hints, and refreshes the icon.
+func (s *scraper) updateFeedMetadata(ctx context.Context, feed database.Feed, doc feedDocument) {
+	metadata := normalizeFeedMetadata(doc, feed.Url)
+
+	skipHours := make([]int32, 0, len(doc.Hints.SkipHours))
+	for _, hour := range doc.Hints.SkipHours {
+		skipHours = append(skipHours, int32(hour))
+	}
+
+	skipDays := make([]int32, 0, len(doc.Hints.SkipDays))
+	for _, day := range doc.Hints.SkipDays {
+		skipDays = append(skipDays, int32(day))
+	}
+
+	err := s.db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		ID:            feed.ID,
+		Title:         sql.NullString{String: metadata.Title, Valid: metadata.Title != ""},
+		Description:   sql.NullString{String: metadata.Description, Valid: metadata.Description != ""},
+		SiteUrl:       sql.NullString{String: metadata.SiteURL, Valid: metadata.SiteURL != ""},
+		IconUrl:       sql.NullString{String: metadata.IconURL, Valid: metadata.IconURL != ""},
+		Language:      sql.NullString{String: metadata.Language, Valid: metadata.Language != ""},
+		LastBuildDate: sql.NullTime{Time: metadata.LastBuildDate, Valid: metadata.HasBuildDate},
+
+		UpdateIntervalSeconds: sql.NullInt32{Int32: int32(doc.Hints.Interval / time.Second), Valid: doc.Hints.Interval > 0},
+		SkipHours:             skipHours,
+		SkipDays:              skipDays,
+	})
+	if err != nil {
+		log.Println("error updating feed metadata", err)
+	}
+
+	s.refreshFeedIcon(ctx, feed, metadata.IconURL, metadata.SiteURL)
+}
+
+// storeEnclosures attaches the enclosures of an item, together with its
+// podcast metadata, to the stored post.
+func (s *scraper) storeEnclosures(ctx context.Context, feed database.Feed, post normalizedItem) {
+	for _, enclosure := range post.Enclosures {
+		enclosureURL, ok := resolveURL(enclosure.URL, post.Base)
+		if !ok {
+			continue
+		}
+
+		imageURL := ""
+		if post.Podcast.Image != "" {
+			imageURL, _ = resolveURL(post.Podcast.Image, post.Base)
+		}
+
+		err := s.db.UpsertEnclosure(ctx, database.UpsertEnclosureParams{
+			ID:              uuid.New(),
+			CreatedAt:       time.Now().UTC(),
+			UpdatedAt:       time.Now().UTC(),
+			Url:             enclosureURL,
+			MimeType:        sql.NullString{String: enclosure.Type, Valid: enclosure.Type != ""},
+			Length:          sql.NullInt64{Int64: enclosure.Length, Valid: enclosure.Length > 0},
+			DurationSeconds: sql.NullInt32{Int32: int32(post.Podcast.DurationSeconds), Valid: post.Podcast.DurationSeconds > 0},
+			Episode:         sql.NullInt32{Int32: int32(post.Podcast.Episode), Valid: post.Podcast.Episode > 0},
+			Season:          sql.NullInt32{Int32: int32(post.Podcast.Season), Valid: post.Podcast.Season > 0},
+			ImageUrl:        sql.NullString{String: imageURL, Valid: imageURL != ""},
+			FeedID:          feed.ID,
+			Guid:            post.Identity,
+		})
+		if err != nil {
+			log.Println("failed to upsert enclosure", err)
+		}
+	}
+}
+
+// run starts the workers and feeds them due feeds until ctx is cancelled.
+func (s *scraper) run(ctx context.Context) {
+	log.Printf("Scraping on %v workers, refreshing feeds every %s", s.workers, s.interval)
+
+	queue := make(chan database.Feed)
+	// Every worker announces on ready that it is idle before it waits for
+	// the next feed, so it never holds more than one token.
+	ready := make(chan struct{}, s.workers)
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for {
+				ready <- struct{}{}
+
+				feed, ok := <-queue
+				if !ok {
+					return
+				}
+				s.scrapeFeed(ctx, feed)
+			}
+		}()
+	}
+
+	s.dispatch(ctx, queue, ready)
+
+	close(queue)
+	waitGroup.Wait()
+}
+
+// dispatch hands due feeds to idle workers. Only as many feeds are claimed as
+// there are idle workers, so a claimed feed is fetched right away and the
+// lease only has to cover a single fetch. Feeds are claimed for the lease
+// duration, so other instances skip them until the fetch is recorded or the
+// lease of a crashed instance expires.
+func (s *scraper) dispatch(ctx context.Context, queue chan<- database.Feed, ready <-chan struct{}) {
+	idle := 0
+	for {
+		if idle == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ready:
+				idle++
+			}
+		}
+
+		for waiting := true; waiting; {
+			select {
+			case <-ready:
+				idle++
+			default:
+				waiting = false
+			}
+		}
+
+		limit := idle
+		feeds, err := s.db.ClaimNextFeedsToFetch(ctx, database.ClaimNextFeedsToFetchParams{
+			ClaimedUntil: sql.NullTime{Time: time.Now().UTC().Add(s.lease), Valid: true},
+			Limit:        int32(limit),
+		})
+		if err != nil {
+			log.Println("error claiming feeds", err)
+		}
+
+		for _, feed := range feeds {
+			select {
+			case <-ctx.Done():
+				return
+			case queue <- feed:
+				idle--
+			}
+		}
+
+		// A full batch means more feeds are probably due right away.
+		if err == nil && len(feeds) == limit {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsFeedGone(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "gone", err: statusCodeError{StatusCode: 410}, want: true},
+		{name: "wrapped gone", err: fmt.Errorf("fetching feed: %w", statusCodeError{StatusCode: 410}), want: true},
+		{name: "not found", err: statusCodeError{StatusCode: 404}, want: false},
+		{name: "server error", err: statusCodeError{StatusCode: 503}, want: false},
+		{name: "blocked address", err: fmt.Errorf("%w: 10.0.0.1", errBlockedAddress), want: false},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFeedGone(tt.err); got != tt.want {
+				t.Errorf("isFeedGone(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+-- name: UpsertEnclosure :exec
+INSERT INTO enclosures (
+  id,
+  created_at,
+  updated_at,
+  post_id,
+  url,
+  mime_type,
+  length,
+  duration_seconds,
+  episode,
+  season,
+  image_url
+)
+SELECT
+  sqlc.arg('id'),
+  sqlc.arg('created_at'),
+  sqlc.arg('updated_at'),
+  posts.id,
+  sqlc.arg('url'),
+  sqlc.arg('mime_type'),
+  sqlc.arg('length'),
+  sqlc.arg('duration_seconds'),
+  sqlc.arg('episode'),
+  sqlc.arg('season'),
+  sqlc.arg('image_url')
+FROM posts
+WHERE posts.feed_id = sqlc.arg('feed_id') AND posts.guid = sqlc.arg('guid')
+ON CONFLICT (post_id, url) DO UPDATE
+SET
+  mime_type = EXCLUDED.mime_type,
+  length = EXCLUDED.length,
+  duration_seconds = EXCLUDED.duration_seconds,
+  episode = EXCLUDED.episode,
+  season = EXCLUDED.season,
+  image_url = EXCLUDED.image_url,
+  updated_at = EXCLUDED.updated_at;
+
+-- name: GetEnclosuresForPosts :many
+SELECT * FROM enclosures
+WHERE post_id = ANY(sqlc.arg('post_ids')::uuid[])
+ORDER BY created_at;
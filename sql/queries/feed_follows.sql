@@ -9,6 +9,19 @@ INSERT INTO feed_follows (
 VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
+-- name: UpsertFeedFollow :one
+INSERT INTO feed_follows (
+  id,
+  user_id,
+  feed_id,
+  created_at,
+  updated_at
+)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id, user_id) DO UPDATE
+SET updated_at = feed_follows.updated_at
+RETURNING *;
+
 -- name: GetFeedFollow :one
 SELECT * FROM feed_follows WHERE id = $1 LIMIT 1;
 
@@ -16,4 +29,14 @@ SELECT * FROM feed_follows WHERE id = $1 LIMIT 1;
 DELETE FROM feed_follows WHERE id = $1;
 
 -- name: GetFeedFollowsOfUser :many
-SELECT * FROM feed_follows WHERE user_id = $1;
\ No newline at end of file
+SELECT * FROM feed_follows WHERE user_id = $1;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET
+  feed_id = sqlc.arg('to_feed_id'),
+  updated_at = NOW()
+WHERE feed_id = sqlc.arg('from_feed_id')
+  AND user_id NOT IN (
+    SELECT user_id FROM feed_follows WHERE feed_id = sqlc.arg('to_feed_id')
+  );
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- name: UpsertFeedIcon :exec
+INSERT INTO feed_icons (
+  feed_id,
+  created_at,
+  updated_at,
+  checked_at,
+  url,
+  content_type,
+  hash,
+  data,
+  announced_url
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (feed_id) DO UPDATE
+SET
+  url = EXCLUDED.url,
+  content_type = EXCLUDED.content_type,
+  hash = EXCLUDED.hash,
+  data = EXCLUDED.data,
+  checked_at = EXCLUDED.checked_at,
+  announced_url = EXCLUDED.announced_url,
+  updated_at = CASE
+    WHEN feed_icons.hash = EXCLUDED.hash THEN feed_icons.updated_at
+    ELSE EXCLUDED.updated_at
+  END;
+
+-- name: MarkFeedIconChecked :exec
+INSERT INTO feed_icons (
+  feed_id,
+  created_at,
+  updated_at,
+  checked_at,
+  announced_url
+)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feed_id) DO UPDATE
+SET
+  checked_at = EXCLUDED.checked_at,
+  announced_url = EXCLUDED.announced_url;
+
+-- name: GetFeedIcon :one
+SELECT * FROM feed_icons WHERE feed_id = $1 LIMIT 1;
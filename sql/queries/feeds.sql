@@ -17,15 +17,115 @@ FROM feeds;
 -- name: GetFeed :one
 SELECT * FROM feeds WHERE id = $1 LIMIT 1;
 
--- name: GetNextFeedsToFetch :many
+-- name: GetFeedByUrls :one
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1;
+WHERE url = ANY(sqlc.arg('urls')::text[])
+ORDER BY created_at
+LIMIT 1;
+
+-- name: GetOtherFeedByUrls :one
+SELECT * FROM feeds
+WHERE url = ANY(sqlc.arg('urls')::text[]) AND id <> sqlc.arg('id')
+ORDER BY created_at
+LIMIT 1;
+
+-- name: ClaimNextFeedsToFetch :many
+UPDATE feeds
+SET
+  claimed_until = sqlc.arg('claimed_until'),
+  updated_at = NOW()
+WHERE id IN (
+  SELECT id FROM feeds
+  WHERE disabled_at IS NULL
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+  ORDER BY next_fetch_at ASC NULLS FIRST, poll_interval_seconds ASC NULLS LAST, last_fetched_at ASC NULLS FIRST
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
 
 -- name: MarkFeedFetched :one
 UPDATE feeds
 SET
   last_fetched_at = NOW(),
+  next_fetch_at = $2,
+  consecutive_failures = 0,
+  claimed_until = NULL,
   updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET
+  etag = $2,
+  last_modified = $3,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET
+  title = $2,
+  description = $3,
+  site_url = $4,
+  icon_url = $5,
+  language = $6,
+  last_build_date = $7,
+  update_interval_seconds = $8,
+  skip_hours = $9,
+  skip_days = $10,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateFeedPollInterval :exec
+UPDATE feeds
+SET
+  poll_interval_seconds = $2,
+  next_fetch_at = $3,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET
+  consecutive_failures = consecutive_failures + 1,
+  last_error = $2,
+  last_error_at = NOW(),
+  next_fetch_at = $3,
+  claimed_until = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableFeed :exec
+UPDATE feeds
+SET
+  disabled_at = NOW(),
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET
+  url = COALESCE(sqlc.narg('url'), url),
+  etag = CASE WHEN sqlc.narg('url') <> url THEN NULL ELSE etag END,
+  last_modified = CASE WHEN sqlc.narg('url') <> url THEN NULL ELSE last_modified END,
+  last_error = CASE WHEN sqlc.narg('url') <> url THEN NULL ELSE last_error END,
+  last_error_at = CASE WHEN sqlc.narg('url') <> url THEN NULL ELSE last_error_at END,
+  disabled_at = NULL,
+  consecutive_failures = 0,
+  next_fetch_at = NULL,
+  updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET
+  url = $2,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
\ No newline at end of file
@@ -1,4 +1,35 @@
--- name: CreatePost :one
+-- name: GetPostsForUser :many
+SELECT posts.* FROM posts
+JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('category')::text IS NULL
+    OR EXISTS (SELECT 1 FROM unnest(posts.categories) AS category WHERE lower(category) = lower(sqlc.narg('category')))
+  )
+  AND (
+    sqlc.narg('author')::text IS NULL
+    OR EXISTS (SELECT 1 FROM unnest(posts.authors) AS author WHERE lower(author) = lower(sqlc.narg('author')))
+  )
+ORDER BY posts.published_at desc
+LIMIT sqlc.arg('limit');
+
+-- name: GetRecentPostTimes :many
+SELECT published_at FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
+LIMIT $2;
+
+-- name: MovePosts :exec
+UPDATE posts
+SET
+  feed_id = sqlc.arg('to_feed_id'),
+  updated_at = NOW()
+WHERE feed_id = sqlc.arg('from_feed_id')
+  AND guid NOT IN (
+    SELECT guid FROM posts WHERE feed_id = sqlc.arg('to_feed_id')
+  );
+
+-- name: UpsertPost :one
 INSERT INTO posts (
   id,
   created_at,
@@ -7,14 +38,29 @@ INSERT INTO posts (
   description,
   url,
   published_at,
-  feed_id
+  feed_id,
+  unparsed_published_at,
+  guid,
+  content,
+  excerpt,
+  authors,
+  categories
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING *;
-
--- name: GetPostsForUser :many
-SELECT posts.* FROM posts
-JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at desc
-LIMIT $2;
\ No newline at end of file
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+  title = EXCLUDED.title,
+  description = EXCLUDED.description,
+  url = EXCLUDED.url,
+  content = EXCLUDED.content,
+  excerpt = EXCLUDED.excerpt,
+  authors = EXCLUDED.authors,
+  categories = EXCLUDED.categories,
+  updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+  OR posts.description IS DISTINCT FROM EXCLUDED.description
+  OR posts.url IS DISTINCT FROM EXCLUDED.url
+  OR posts.content IS DISTINCT FROM EXCLUDED.content
+  OR posts.authors IS DISTINCT FROM EXCLUDED.authors
+  OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN unparsed_published_at TEXT;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN unparsed_published_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN authors TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE posts ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN categories;
+ALTER TABLE posts DROP COLUMN authors;
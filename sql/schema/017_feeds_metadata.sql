@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE feeds ALTER COLUMN name DROP NOT NULL;
+ALTER TABLE feeds ADD COLUMN title TEXT;
+ALTER TABLE feeds ADD COLUMN description TEXT;
+ALTER TABLE feeds ADD COLUMN site_url TEXT;
+ALTER TABLE feeds ADD COLUMN icon_url TEXT;
+ALTER TABLE feeds ADD COLUMN language TEXT;
+ALTER TABLE feeds ADD COLUMN last_build_date TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN last_build_date;
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE feeds DROP COLUMN icon_url;
+ALTER TABLE feeds DROP COLUMN site_url;
+ALTER TABLE feeds DROP COLUMN description;
+UPDATE feeds SET name = COALESCE(title, url) WHERE name IS NULL;
+ALTER TABLE feeds DROP COLUMN title;
+ALTER TABLE feeds ALTER COLUMN name SET NOT NULL;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE feed_icons(
+  feed_id UUID PRIMARY KEY REFERENCES feeds (id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  checked_at TIMESTAMP NOT NULL,
+  url TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  hash TEXT NOT NULL,
+  data BYTEA NOT NULL
+);
+
+-- +goose Down
+DROP TABLE feed_icons;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN poll_interval_seconds INTEGER;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN poll_interval_seconds;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE feed_icons ADD COLUMN announced_url TEXT;
+ALTER TABLE feed_icons ALTER COLUMN url DROP NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN content_type DROP NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN hash DROP NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN data DROP NOT NULL;
+
+-- +goose Down
+DELETE FROM feed_icons WHERE data IS NULL;
+ALTER TABLE feed_icons ALTER COLUMN data SET NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN hash SET NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN content_type SET NOT NULL;
+ALTER TABLE feed_icons ALTER COLUMN url SET NOT NULL;
+ALTER TABLE feed_icons DROP COLUMN announced_url;
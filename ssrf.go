@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+var errBlockedAddress = errors.New("connection to blocked address")
+
+// blockedNetworks are the ranges not covered by the net.IP classification
+// methods used in isPrivateIP.
+var blockedNetworks = []*net.IPNet{
+	// "This network", RFC 791.
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	// Shared address space of carrier-grade NAT, RFC 6598. Some cloud
+	// providers serve instance metadata from it.
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	// IETF protocol assignments, RFC 6890.
+	{IP: net.IPv4(192, 0, 0, 0), Mask: net.CIDRMask(24, 32)},
+	// Benchmarking, RFC 2544.
+	{IP: net.IPv4(198, 18, 0, 0), Mask: net.CIDRMask(15, 32)},
+	// Reserved, RFC 1112, including the broadcast address.
+	{IP: net.IPv4(240, 0, 0, 0), Mask: net.CIDRMask(4, 32)},
+	// NAT64, RFC 6052. It embeds IPv4 addresses, e.g. 64:ff9b::a9fe:a9fe is
+	// 169.254.169.254 on networks with a NAT64 gateway.
+	{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)},
+}
+
+// isPrivateIP reports whether ip belongs to a loopback, link-local, private
+// or otherwise non-public network. The cloud metadata endpoints at
+// 169.254.169.254 and fd00:ec2::254 are link-local and private respectively.
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseNetworks parses a comma separated list of CIDR ranges and single IP
+// addresses.
+func parseNetworks(value string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, err
+			}
+			networks = append(networks, network)
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return networks, nil
+}
+
+// allowsIP reports whether the fetcher may connect to ip.
+func (config fetcherConfig) allowsIP(ip net.IP) bool {
+	if config.AllowPrivateNetworks || !isPrivateIP(ip) {
+		return true
+	}
+
+	for _, network := range config.AllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dialControl is called by the dialer after the host name was resolved, right
+// before every connection attempt. Checking the address here covers every
+// redirect and prevents DNS rebinding between a check and the connection.
+func (config fetcherConfig) dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !config.allowsIP(ip) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		// Blocked IPv4 ranges.
+		{ip: "0.0.0.0", want: true},
+		{ip: "0.1.2.3", want: true},
+		{ip: "10.0.0.1", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "100.127.255.254", want: true},
+		{ip: "127.0.0.1", want: true},
+		{ip: "127.255.255.254", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "172.31.255.254", want: true},
+		{ip: "192.0.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "198.18.0.1", want: true},
+		{ip: "198.19.255.254", want: true},
+		{ip: "224.0.0.1", want: true},
+		{ip: "240.0.0.1", want: true},
+		{ip: "255.255.255.255", want: true},
+
+		// Blocked IPv6 ranges.
+		{ip: "::", want: true},
+		{ip: "::1", want: true},
+		{ip: "fc00::1", want: true},
+		{ip: "fd00:ec2::254", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "ff02::1", want: true},
+		{ip: "64:ff9b::a9fe:a9fe", want: true},
+		{ip: "64:ff9b::7f00:1", want: true},
+
+		// IPv4-mapped IPv6 addresses are checked as IPv4.
+		{ip: "::ffff:127.0.0.1", want: true},
+		{ip: "::ffff:10.0.0.1", want: true},
+		{ip: "::ffff:169.254.169.254", want: true},
+		{ip: "::ffff:100.64.0.1", want: true},
+		{ip: "::ffff:7f00:1", want: true},
+		{ip: "::ffff:8.8.8.8", want: false},
+
+		// Public addresses.
+		{ip: "1.1.1.1", want: false},
+		{ip: "8.8.8.8", want: false},
+		{ip: "100.63.255.255", want: false},
+		{ip: "100.128.0.1", want: false},
+		{ip: "172.32.0.1", want: false},
+		{ip: "198.20.0.1", want: false},
+		{ip: "2606:4700::1111", want: false},
+		{ip: "2001:4860:4860::8888", want: false},
+		{ip: "64:ff9c::1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid test address %q", tt.ip)
+			}
+
+			if got := isPrivateIP(ip); got != tt.want {
+				t.Errorf("isPrivateIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  []string{},
+		},
+		{
+			name:  "ranges and addresses",
+			value: "10.0.0.0/8, 192.168.1.10 ,fd00::/8,::1",
+			want:  []string{"10.0.0.0/8", "192.168.1.10/32", "fd00::/8", "::1/128"},
+		},
+		{
+			name:  "empty entries are skipped",
+			value: ",10.1.2.0/24,,",
+			want:  []string{"10.1.2.0/24"},
+		},
+		{
+			name:    "invalid range",
+			value:   "10.0.0.0/33",
+			wantErr: true,
+		},
+		{
+			name:    "invalid address",
+			value:   "localhost",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks, err := parseNetworks(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseNetworks(%q) returned no error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNetworks(%q) returned error: %v", tt.value, err)
+			}
+
+			if len(networks) != len(tt.want) {
+				t.Fatalf("parseNetworks(%q) = %v, want %v", tt.value, networks, tt.want)
+			}
+			for i, network := range networks {
+				if network.String() != tt.want[i] {
+					t.Errorf("parseNetworks(%q)[%d] = %s, want %s", tt.value, i, network, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAllowsIP(t *testing.T) {
+	allowed, err := parseNetworks("10.1.0.0/16,192.168.1.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config fetcherConfig
+		ip     string
+		want   bool
+	}{
+		{name: "public address", config: fetcherConfig{}, ip: "93.184.216.34", want: true},
+		{name: "private address", config: fetcherConfig{}, ip: "10.1.2.3", want: false},
+		{name: "metadata endpoint", config: fetcherConfig{}, ip: "169.254.169.254", want: false},
+		{name: "mapped metadata endpoint", config: fetcherConfig{}, ip: "::ffff:169.254.169.254", want: false},
+		{name: "private networks allowed", config: fetcherConfig{AllowPrivateNetworks: true}, ip: "127.0.0.1", want: true},
+		{name: "allow-listed range", config: fetcherConfig{AllowedNetworks: allowed}, ip: "10.1.2.3", want: true},
+		{name: "mapped allow-listed range", config: fetcherConfig{AllowedNetworks: allowed}, ip: "::ffff:10.1.2.3", want: true},
+		{name: "allow-listed address", config: fetcherConfig{AllowedNetworks: allowed}, ip: "192.168.1.10", want: true},
+		{name: "outside allow-list", config: fetcherConfig{AllowedNetworks: allowed}, ip: "10.2.0.1", want: false},
+		{name: "neighbour of allow-listed address", config: fetcherConfig{AllowedNetworks: allowed}, ip: "192.168.1.11", want: false},
+		{name: "loopback with allow-list", config: fetcherConfig{AllowedNetworks: allowed}, ip: "127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.allowsIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("allowsIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "93.184.216.34:443", wantErr: false},
+		{address: "[2606:4700::1111]:443", wantErr: false},
+		{address: "127.0.0.1:80", wantErr: true},
+		{address: "[::1]:80", wantErr: true},
+		{address: "[::ffff:169.254.169.254]:80", wantErr: true},
+		{address: "[64:ff9b::a9fe:a9fe]:80", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := fetcherConfig{}.dialControl("tcp", tt.address, nil)
+			if tt.wantErr && !errors.Is(err, errBlockedAddress) {
+				t.Errorf("dialControl(%s) = %v, want %v", tt.address, err, errBlockedAddress)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("dialControl(%s) returned error: %v", tt.address, err)
+			}
+		})
+	}
+}